@@ -0,0 +1,277 @@
+package katapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Component is a part of the application with managed lifecycle (HTTP server, database pool,
+// cache runners, leader elector etc.)
+type Component interface {
+	// Name is a unique name of the component, it is used to declare dependencies and for logging
+	Name() string
+	// Start starts the component. It must not block for the lifetime of the component, long-running
+	// work must be performed in background until context is cancelled (right before components are stopped).
+	Start(ctx context.Context) error
+	// Stop stops the component, context deadline is set to the application shutdown timeout
+	Stop(ctx context.Context) error
+}
+
+// ReadinessChecker can be optionally implemented by components to report if they are ready to serve
+type ReadinessChecker interface {
+	Ready(ctx context.Context) error
+}
+
+// NewComponent creates a component from start and stop functions (both are optional)
+func NewComponent(name string, start func(ctx context.Context) error, stop func(ctx context.Context) error) Component {
+	return &funcComponent{name: name, start: start, stop: stop}
+}
+
+type funcComponent struct {
+	name  string
+	start func(ctx context.Context) error
+	stop  func(ctx context.Context) error
+}
+
+func (c *funcComponent) Name() string {
+	return c.name
+}
+
+func (c *funcComponent) Start(ctx context.Context) error {
+	if c.start == nil {
+		return nil
+	}
+	return c.start(ctx)
+}
+
+func (c *funcComponent) Stop(ctx context.Context) error {
+	if c.stop == nil {
+		return nil
+	}
+	return c.stop(ctx)
+}
+
+// App manages lifecycle of application components. Components are started in order of their
+// dependencies and stopped in reverse order when interrupt (or SIGTERM) signal is received,
+// context is cancelled or any component reports a fatal failure (see Fail), e.g.
+//
+//	app := katapp.NewApp(30 * time.Second)
+//	app.Add(db.Component())                      // "postgres"
+//	app.Add(cache.Component(), "postgres")        // "kvtcache"
+//	app.Add(kathttp_std.ServerComponent(&cfg.Server, logger, setup), "postgres", "kvtcache")
+//	app.Add(katapp.NewComponent("reports", startReports, stopReports), "postgres")
+//	if err := app.Run(ctx); err != nil {
+//		katapp.Logger(ctx).Fatalf("application has failed: %v", err)
+//	}
+//
+// Components of gokatana are provided by katpg (DBLink, KVTCache and LeaderElector) and by
+// every HTTP server adapter (ServerComponent).
+type App struct {
+	shutdownTimeout time.Duration
+	components      []appComponent
+
+	mu      sync.Mutex
+	started []Component
+	failed  chan error
+}
+
+type appComponent struct {
+	component Component
+	dependsOn []string
+}
+
+// NewApp creates application with a timeout to stop all components
+func NewApp(shutdownTimeout time.Duration) *App {
+	return &App{
+		shutdownTimeout: shutdownTimeout,
+		failed:          make(chan error, 1),
+	}
+}
+
+// Add registers component that depends on components with specified names (they must be registered
+// as well, but can be added later). It panics if component with the same name was already registered.
+func (a *App) Add(c Component, dependsOn ...string) {
+	for _, ac := range a.components {
+		if ac.component.Name() == c.Name() {
+			panic(fmt.Sprintf("component %s was already registered", c.Name()))
+		}
+	}
+	a.components = append(a.components, appComponent{component: c, dependsOn: dependsOn})
+}
+
+// Fail reports a fatal failure of a component, it triggers a coordinated shutdown of the application
+// and Run returns the error. Only the first failure is reported, subsequent failures are ignored.
+// Components can report failures of their background work with FailApp.
+func (a *App) Fail(err error) {
+	select {
+	case a.failed <- err:
+	default:
+	}
+}
+
+type appContextKey struct{}
+
+// FailApp reports a fatal failure (see App.Fail) to the application that started component with the context.
+// It is meant for background work of components, failure is only logged if the context has no running application.
+func FailApp(ctx context.Context, err error) {
+	if app, ok := ctx.Value(appContextKey{}).(*App); ok {
+		app.Fail(err)
+		return
+	}
+	Logger(ctx).ErrorContext(ctx, "component has failed", "error", err)
+}
+
+// Ready checks that all components were started and are ready (see ReadinessChecker)
+func (a *App) Ready(ctx context.Context) error {
+	a.mu.Lock()
+	started := slices.Clone(a.started)
+	a.mu.Unlock()
+	if len(started) != len(a.components) {
+		return errors.New("application is not started")
+	}
+	for _, c := range started {
+		if rc, ok := c.(ReadinessChecker); ok {
+			if err := rc.Ready(ctx); err != nil {
+				return fmt.Errorf("component %s is not ready: %w", c.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Run starts all components and blocks until application is stopped. It returns error if any of
+// components failed to start, reported a fatal failure (see Fail) or failed to stop.
+func (a *App) Run(ctx context.Context) error {
+	logger := Logger(ctx).WithGroup("katapp.App")
+	order, err := a.startOrder()
+	if err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancel(context.WithValue(ctx, appContextKey{}, a))
+	defer cancel()
+	sigCtx, stopSignals := signal.NotifyContext(runCtx, os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	var runErr error
+	for _, c := range order {
+		logger.InfoContext(ctx, "starting component", "component", c.Name())
+		if err := c.Start(runCtx); err != nil {
+			runErr = fmt.Errorf("failed to start component %s: %w", c.Name(), err)
+			logger.ErrorContext(ctx, "failed to start component", "component", c.Name(), "error", err)
+			break
+		}
+		a.mu.Lock()
+		a.started = append(a.started, c)
+		a.mu.Unlock()
+	}
+
+	if runErr == nil {
+		logger.InfoContext(ctx, "application is started")
+		select {
+		case <-sigCtx.Done():
+			if ctx.Err() == nil {
+				logger.InfoContext(ctx, "shutdown signal has been received")
+			}
+		case err := <-a.failed:
+			runErr = err
+			logger.ErrorContext(ctx, "application has failed, shutting down", "error", err)
+		}
+	}
+
+	// background work of components exits while they are being stopped
+	cancel()
+	if err := a.stop(ctx); err != nil {
+		runErr = errors.Join(runErr, err)
+	}
+	logger.InfoContext(ctx, "application is stopped")
+	return runErr
+}
+
+// stop stops started components in reverse order within the shutdown timeout
+func (a *App) stop(ctx context.Context) error {
+	logger := Logger(ctx).WithGroup("katapp.App")
+	stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), a.shutdownTimeout)
+	defer cancel()
+
+	a.mu.Lock()
+	started := a.started
+	a.started = nil
+	a.mu.Unlock()
+
+	var errs []error
+	for _, c := range slices.Backward(started) {
+		logger.InfoContext(ctx, "stopping component", "component", c.Name())
+		if err := stopComponent(stopCtx, c); err != nil {
+			logger.ErrorContext(ctx, "failed to stop component", "component", c.Name(), "error", err)
+			errs = append(errs, fmt.Errorf("failed to stop component %s: %w", c.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stopComponent stops component, but does not wait for it longer than context deadline
+// (so a single stuck component cannot block shutdown of others)
+func stopComponent(ctx context.Context, c Component) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Stop(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startOrder sorts components topologically, so every component goes after its dependencies.
+// Components without dependencies between them keep their registration order.
+func (a *App) startOrder() ([]Component, error) {
+	byName := make(map[string]appComponent, len(a.components))
+	for _, ac := range a.components {
+		byName[ac.component.Name()] = ac
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(a.components))
+	var order []Component
+	var visit func(ac appComponent, path []string) error
+	visit = func(ac appComponent, path []string) error {
+		name := ac.component.Name()
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle between components: %v", append(path, name))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range ac.dependsOn {
+			depComponent, ok := byName[dep]
+			if !ok {
+				return fmt.Errorf("component %s depends on unknown component %s", name, dep)
+			}
+			if err := visit(depComponent, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		order = append(order, ac.component)
+		return nil
+	}
+	for _, ac := range a.components {
+		if err := visit(ac, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
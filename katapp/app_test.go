@@ -0,0 +1,183 @@
+package katapp_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kattest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type lifecycleRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *lifecycleRecorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *lifecycleRecorder) Events() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func (r *lifecycleRecorder) component(name string, startErr error) katapp.Component {
+	return katapp.NewComponent(name, func(ctx context.Context) error {
+		r.record("start " + name)
+		return startErr
+	}, func(ctx context.Context) error {
+		r.record("stop " + name)
+		return nil
+	})
+}
+
+func TestApp_StartsInDependencyOrderAndStopsInReverse(t *testing.T) {
+	ctx, cancel := context.WithCancel(kattest.AppTestContext())
+	rec := &lifecycleRecorder{}
+	app := katapp.NewApp(time.Second)
+	app.Add(rec.component("http", nil), "db", "cache")
+	app.Add(rec.component("cache", nil), "db")
+	app.Add(rec.component("db", nil))
+	app.Add(rec.component("metrics", nil))
+
+	done := make(chan error)
+	go func() {
+		done <- app.Run(ctx)
+	}()
+	assert.Eventually(t, func() bool {
+		return app.Ready(ctx) == nil
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	assert.Equal(t, []string{
+		"start db", "start cache", "start http", "start metrics",
+		"stop metrics", "stop http", "stop cache", "stop db",
+	}, rec.Events())
+	assert.Error(t, app.Ready(ctx))
+}
+
+func TestApp_CancelsContextBeforeStoppingComponents(t *testing.T) {
+	ctx, cancel := context.WithCancel(kattest.AppTestContext())
+	app := katapp.NewApp(time.Second)
+	var runCtx context.Context
+	var cancelledOnStop bool
+	app.Add(katapp.NewComponent("worker", func(ctx context.Context) error {
+		runCtx = ctx
+		return nil
+	}, func(ctx context.Context) error {
+		cancelledOnStop = runCtx.Err() != nil
+		return nil
+	}))
+
+	done := make(chan error)
+	go func() {
+		done <- app.Run(ctx)
+	}()
+	assert.Eventually(t, func() bool {
+		return app.Ready(ctx) == nil
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+	assert.True(t, cancelledOnStop)
+}
+
+func TestApp_StopsStartedComponentsWhenStartFails(t *testing.T) {
+	rec := &lifecycleRecorder{}
+	app := katapp.NewApp(time.Second)
+	app.Add(rec.component("db", nil))
+	app.Add(rec.component("cache", errors.New("boom")), "db")
+	app.Add(rec.component("http", nil), "cache")
+
+	err := app.Run(kattest.AppTestContext())
+	assert.ErrorContains(t, err, "failed to start component cache: boom")
+	assert.Equal(t, []string{"start db", "start cache", "stop db"}, rec.Events())
+}
+
+func TestApp_FatalFailureTriggersShutdown(t *testing.T) {
+	rec := &lifecycleRecorder{}
+	app := katapp.NewApp(time.Second)
+	failure := errors.New("leadership lost")
+	app.Add(rec.component("db", nil))
+	app.Add(katapp.NewComponent("leader", func(ctx context.Context) error {
+		go app.Fail(failure)
+		return nil
+	}, nil), "db")
+
+	err := app.Run(kattest.AppTestContext())
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, []string{"start db", "stop db"}, rec.Events())
+}
+
+func TestApp_FailAppReportsFailureOfComponent(t *testing.T) {
+	rec := &lifecycleRecorder{}
+	app := katapp.NewApp(time.Second)
+	failure := errors.New("server has failed")
+	app.Add(rec.component("db", nil))
+	app.Add(katapp.NewComponent("http", func(ctx context.Context) error {
+		go katapp.FailApp(ctx, failure)
+		return nil
+	}, nil), "db")
+
+	err := app.Run(kattest.AppTestContext())
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, []string{"start db", "stop db"}, rec.Events())
+}
+
+func TestApp_StopsWithinShutdownTimeout(t *testing.T) {
+	rec := &lifecycleRecorder{}
+	app := katapp.NewApp(50 * time.Millisecond)
+	app.Add(rec.component("db", nil))
+	app.Add(katapp.NewComponent("stuck", nil, func(ctx context.Context) error {
+		select {}
+	}), "db")
+
+	ctx, cancel := context.WithCancel(kattest.AppTestContext())
+	cancel()
+	started := time.Now()
+	err := app.Run(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(started), time.Second)
+	// remaining components are still asked to stop after the deadline
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"start db", "stop db"}, rec.Events())
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestApp_HandlesSigterm(t *testing.T) {
+	rec := &lifecycleRecorder{}
+	app := katapp.NewApp(time.Second)
+	app.Add(rec.component("db", nil))
+	app.Add(katapp.NewComponent("signal", func(ctx context.Context) error {
+		return syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	}, nil))
+
+	require.NoError(t, app.Run(kattest.AppTestContext()))
+	assert.Equal(t, []string{"start db", "stop db"}, rec.Events())
+}
+
+func TestApp_ReportsInvalidDependencies(t *testing.T) {
+	ctx := kattest.AppTestContext()
+	app := katapp.NewApp(time.Second)
+	app.Add(katapp.NewComponent("a", nil, nil), "b")
+	app.Add(katapp.NewComponent("b", nil, nil), "a")
+	assert.ErrorContains(t, app.Run(ctx), "dependency cycle")
+
+	app = katapp.NewApp(time.Second)
+	app.Add(katapp.NewComponent("a", nil, nil), "unknown")
+	assert.ErrorContains(t, app.Run(ctx), "unknown component unknown")
+
+	assert.Panics(t, func() {
+		app.Add(katapp.NewComponent("a", nil, nil))
+	})
+}
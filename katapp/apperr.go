@@ -1,5 +1,11 @@
 package katapp
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
 type ErrScope int
 
 const (
@@ -14,15 +20,180 @@ const (
 	ErrConflict
 )
 
+var errScopeCodes = map[ErrScope]string{
+	ErrUnknown:               "unknown",
+	ErrInternal:              "internal",
+	ErrNotFound:              "not_found",
+	ErrInvalidInput:          "invalid_input",
+	ErrDuplicate:             "duplicate",
+	ErrFailedExternalService: "failed_external_service",
+	ErrUnauthorized:          "unauthorized",
+	ErrNoPermissions:         "no_permissions",
+	ErrConflict:              "conflict",
+}
+
+// String returns a stable code of the scope, e.g. "not_found"
+func (s ErrScope) String() string {
+	if code, ok := errScopeCodes[s]; ok {
+		return code
+	}
+	return errScopeCodes[ErrUnknown]
+}
+
+// Err is an application error. Msg is a user-level message, while Cause is a low-level error
+// (it is available via Unwrap and errors.Is/As, but it is neither part of Error() nor reported to API clients).
 type Err struct {
 	Scope ErrScope
 	Msg   string
+	// Code is a stable machine-readable error code, e.g. "user_email_taken" (scope code is used if empty)
+	Code string
+	// AppCode is an optional numeric application-specific error code
+	AppCode int64
+	// Cause is an underlying error
+	Cause error
+	// Details provides additional structured information about the error
+	Details map[string]any
+	// Violations lists invalid fields of the input
+	Violations []FieldViolation
+	// Retryable hints that the operation may succeed if retried (after RetryAfter, if set)
+	Retryable  bool
+	RetryAfter time.Duration
+
+	stack []uintptr // call stack captured when error was created (see WithStack)
 }
 
 func (e *Err) Error() string {
 	return e.Msg
 }
 
+func (e *Err) Unwrap() error {
+	return e.Cause
+}
+
+// ErrCode returns Code of the error or code of its scope if Code is not set
+func (e *Err) ErrCode() string {
+	if e.Code != "" {
+		return e.Code
+	}
+	return e.Scope.String()
+}
+
+// WithCode sets stable error code
+func (e *Err) WithCode(code string) *Err {
+	e.Code = code
+	return e
+}
+
+// WithAppCode sets numeric application-specific error code
+func (e *Err) WithAppCode(code int64) *Err {
+	e.AppCode = code
+	return e
+}
+
+// WithDetail adds structured detail to the error
+func (e *Err) WithDetail(key string, value any) *Err {
+	if e.Details == nil {
+		e.Details = make(map[string]any)
+	}
+	e.Details[key] = value
+	return e
+}
+
+// WithViolations adds field violations to the error
+func (e *Err) WithViolations(violations ...FieldViolation) *Err {
+	e.Violations = append(e.Violations, violations...)
+	return e
+}
+
+// WithRetry marks error as retryable, retryAfter is optional (can be zero)
+func (e *Err) WithRetry(retryAfter time.Duration) *Err {
+	e.Retryable = true
+	e.RetryAfter = retryAfter
+	return e
+}
+
+// NewErr creates application error (call stack is captured for internal and unknown errors, see SetErrStackCapture)
 func NewErr(scope ErrScope, msg string) *Err {
-	return &Err{Scope: scope, Msg: msg}
+	return newErr(scope, msg, nil)
+}
+
+// Errorf creates application error with formatted message
+func Errorf(scope ErrScope, format string, a ...any) *Err {
+	return newErr(scope, fmt.Sprintf(format, a...), nil)
+}
+
+// Wrap creates application error with formatted message and underlying cause
+func Wrap(scope ErrScope, err error, format string, a ...any) *Err {
+	return newErr(scope, fmt.Sprintf(format, a...), err)
+}
+
+// AsErr returns the first application error in the error chain
+func AsErr(err error) (*Err, bool) {
+	var appErr *Err
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// ScopeOf returns scope of the first application error in the error chain (ErrUnknown if there is none)
+func ScopeOf(err error) ErrScope {
+	if appErr, ok := AsErr(err); ok {
+		return appErr.Scope
+	}
+	return ErrUnknown
+}
+
+// IsRetryable checks if any application error in the error chain is retryable
+func IsRetryable(err error) bool {
+	for err != nil {
+		if appErr, ok := err.(*Err); ok && appErr.Retryable {
+			return true
+		}
+		var next error
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			next = e.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, ue := range e.Unwrap() {
+				if IsRetryable(ue) {
+					return true
+				}
+			}
+		}
+		err = next
+	}
+	return false
+}
+
+func IsNotFound(err error) bool {
+	return ScopeOf(err) == ErrNotFound
+}
+
+func IsInvalidInput(err error) bool {
+	return ScopeOf(err) == ErrInvalidInput
+}
+
+func IsDuplicate(err error) bool {
+	return ScopeOf(err) == ErrDuplicate
+}
+
+func IsConflict(err error) bool {
+	return ScopeOf(err) == ErrConflict
+}
+
+func IsUnauthorized(err error) bool {
+	return ScopeOf(err) == ErrUnauthorized
+}
+
+func IsNoPermissions(err error) bool {
+	return ScopeOf(err) == ErrNoPermissions
+}
+
+func IsFailedExternalService(err error) bool {
+	return ScopeOf(err) == ErrFailedExternalService
+}
+
+func IsInternal(err error) bool {
+	return ScopeOf(err) == ErrInternal
 }
@@ -1,7 +1,12 @@
 package katapp
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewErr(t *testing.T) {
@@ -59,3 +64,101 @@ func TestErr_Error(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expected, err.Error())
 	}
 }
+
+func TestWrap(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := Wrap(ErrFailedExternalService, cause, "failed to call %s", "billing").
+		WithCode("billing_unavailable").
+		WithAppCode(1001).
+		WithDetail("service", "billing").
+		WithRetry(5 * time.Second)
+
+	if err.Error() != "failed to call billing" {
+		t.Errorf("Unexpected error text %q", err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("Expected error to wrap its cause")
+	}
+	if err.ErrCode() != "billing_unavailable" || err.AppCode != 1001 || err.Details["service"] != "billing" {
+		t.Errorf("Unexpected error attributes: %+v", err)
+	}
+
+	wrapped := fmt.Errorf("sync failed: %w", err)
+	if !IsFailedExternalService(wrapped) || IsNotFound(wrapped) {
+		t.Errorf("Expected scope to be detected through wrapping")
+	}
+	if !IsRetryable(wrapped) || IsRetryable(cause) {
+		t.Errorf("Expected retryable hint to be detected through wrapping")
+	}
+	if appErr, ok := AsErr(wrapped); !ok || appErr != err {
+		t.Errorf("Expected AsErr to return application error")
+	}
+}
+
+func TestErr_CodesAndViolations(t *testing.T) {
+	err := NewErr(ErrInvalidInput, "invalid user").WithViolations(
+		FieldViolation{Field: "email", Rule: "required", Message: "is required"},
+	)
+	if err.ErrCode() != "invalid_input" {
+		t.Errorf("Expected scope code, got %q", err.ErrCode())
+	}
+	if len(err.Violations) != 1 || err.Violations[0].Field != "email" {
+		t.Errorf("Unexpected violations: %+v", err.Violations)
+	}
+	if ScopeOf(errors.New("plain")) != ErrUnknown || !IsInvalidInput(err) {
+		t.Errorf("Unexpected scope detection")
+	}
+	if Errorf(ErrNotFound, "user %d not found", 42).Error() != "user 42 not found" {
+		t.Errorf("Unexpected formatted message")
+	}
+}
+
+func newInternalErrAt(msg string) *Err {
+	return NewErr(ErrInternal, msg)
+}
+
+func TestErr_StackAndFingerprint(t *testing.T) {
+	var errs []*Err
+	for i := 0; i < 2; i++ {
+		errs = append(errs, newInternalErrAt(fmt.Sprintf("failure #%d", i)))
+	}
+	if !strings.Contains(errs[0].Stack(), "newInternalErrAt") {
+		t.Errorf("Stack() = %q, must start in newInternalErrAt", errs[0].Stack())
+	}
+	if errs[0].Fingerprint() != errs[1].Fingerprint() {
+		t.Errorf("errors with the same origin have different fingerprints: %s and %s",
+			errs[0].Fingerprint(), errs[1].Fingerprint())
+	}
+	if other := NewErr(ErrInternal, "failure #0"); other.Fingerprint() == errs[0].Fingerprint() {
+		t.Errorf("errors with different origins have the same fingerprint")
+	}
+	wrapped := fmt.Errorf("outer: %w", errs[0])
+	if ErrFingerprint(wrapped) != errs[0].Fingerprint() || ErrStack(wrapped) != errs[0].Stack() {
+		t.Errorf("fingerprint and stack must be taken from wrapped application error")
+	}
+
+	if e := NewErr(ErrNotFound, "not found"); e.Stack() != "" {
+		t.Errorf("stack must not be captured for not found errors")
+	}
+	if e := NewErr(ErrNotFound, "not found").WithStack(); e.Stack() == "" {
+		t.Errorf("stack must be captured with WithStack")
+	}
+	SetErrStackCapture(false)
+	defer SetErrStackCapture(true)
+	if e := NewErr(ErrInternal, "internal"); e.Stack() != "" {
+		t.Errorf("stack must not be captured when capture is disabled")
+	}
+}
+
+func TestMarkErrStackLogged(t *testing.T) {
+	ctx := ContextWithErrStackLogOnce(context.Background())
+	if !MarkErrStackLogged(ctx) {
+		t.Errorf("first MarkErrStackLogged() = false, want true")
+	}
+	if MarkErrStackLogged(ctx) {
+		t.Errorf("second MarkErrStackLogged() = true, want false")
+	}
+	if !MarkErrStackLogged(context.Background()) || !MarkErrStackLogged(context.Background()) {
+		t.Errorf("MarkErrStackLogged() must always be true for untracked contexts")
+	}
+}
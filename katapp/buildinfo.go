@@ -0,0 +1,84 @@
+package katapp
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// Build metadata that can be set at link time (it takes precedence over metadata embedded by go build), e.g.
+//
+//	go build -ldflags "-X github.com/mobiletoly/gokatana/katapp.buildVersion=v1.2.3 \
+//	    -X github.com/mobiletoly/gokatana/katapp.buildRevision=$(git rev-parse HEAD) \
+//	    -X github.com/mobiletoly/gokatana/katapp.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	buildVersion  string
+	buildRevision string
+	buildTime     string
+)
+
+// BuildInfo describes the build of the running binary
+type BuildInfo struct {
+	// Version is a version of the main module, e.g. "v1.2.3" or "(devel)"
+	Version string
+	// Revision is a VCS revision the binary was built from
+	Revision string
+	// Dirty is true if the binary was built from a working tree with uncommitted changes
+	Dirty bool
+	// CommitTime is a time of the VCS revision in RFC3339 format
+	CommitTime string
+	// BuildTime is a time of the build (if set at link time)
+	BuildTime string
+	// GoVersion is a version of Go toolchain the binary was built with
+	GoVersion string
+}
+
+// ReadBuildInfo returns build metadata embedded by go build (see debug.ReadBuildInfo) and set at link time
+func ReadBuildInfo() BuildInfo {
+	var bi BuildInfo
+	if info, ok := debug.ReadBuildInfo(); ok {
+		bi.Version = info.Main.Version
+		bi.GoVersion = info.GoVersion
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				bi.Revision = s.Value
+			case "vcs.modified":
+				bi.Dirty = s.Value == "true"
+			case "vcs.time":
+				bi.CommitTime = s.Value
+			}
+		}
+	}
+	if buildVersion != "" {
+		bi.Version = buildVersion
+	}
+	if buildRevision != "" {
+		bi.Revision = buildRevision
+	}
+	bi.BuildTime = buildTime
+	return bi
+}
+
+func (b BuildInfo) String() string {
+	var sb strings.Builder
+	line := func(name, value string) {
+		if value != "" {
+			_, _ = fmt.Fprintf(&sb, "%-12s %s\n", name+":", value)
+		}
+	}
+	version := b.Version
+	if version == "" {
+		version = "unknown"
+	}
+	line("version", version)
+	revision := b.Revision
+	if revision != "" && b.Dirty {
+		revision += " (dirty)"
+	}
+	line("revision", revision)
+	line("commit time", b.CommitTime)
+	line("build time", b.BuildTime)
+	line("go", b.GoVersion)
+	return sb.String()
+}
@@ -0,0 +1,17 @@
+package katapp
+
+import "time"
+
+// Clock provides current time, it allows to control time in tests (e.g. with kattest.FakeClock)
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is a clock backed by time.Now
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
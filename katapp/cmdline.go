@@ -1,26 +1,133 @@
 package katapp
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
-	"os"
 )
 
-var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Print the version",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("v0.1")
-	},
+// Exit codes of CmdlineExecute
+const (
+	// ExitCodeError is an exit code of a command that failed with an error
+	ExitCodeError = 1
+	// ExitCodeUsage is an exit code of a command with invalid flags
+	ExitCodeUsage = 2
+	// ExitCodeConfig is an exit code of a command that failed to load configuration (EX_CONFIG of sysexits.h)
+	ExitCodeConfig = 78
+)
+
+// ExitError is an error with a specific exit code, it can be returned by commands to control exit code
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns an exit code for the error returned by a command: a code of ExitError,
+// ExitCodeConfig for configuration errors and ExitCodeError for other errors (0 if err is nil)
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	var fileErr *ConfigFileError
+	var decodeErr *ConfigDecodeError
+	var validationErr *ConfigValidationError
+	if errors.As(err, &fileErr) || errors.As(err, &decodeErr) || errors.As(err, &validationErr) {
+		return ExitCodeConfig
+	}
+	return ExitCodeError
 }
 
 type CmdlineHandler struct {
 	Run func(deployment string)
+	// RunE is used instead of Run (if set), returned error is reported and mapped to exit code (see ExitCode)
+	RunE func(deployment string) error
+	// EnvVarPrefix is a prefix of environment variables used by the service configuration
+	// (it is used by "config" subcommands to locate configuration encryption keys)
+	EnvVarPrefix string
+	// LoadConfig loads and validates configuration of the deployment, it is required by
+	// "config validate", "config dump" and "config diff" subcommands and by Commands (see ConfigLoader)
+	LoadConfig func(deployment Deployment) (any, *ConfigProvenance, error)
+	// ConfigSchema generates JSON Schema of the service configuration, it is required by
+	// "config schema" subcommand (e.g. katapp.ConfigSchema[AppConfig])
+	ConfigSchema func() *JSONSchema
+	// PersistentFlags allows to define additional flags available to all commands. Values of the flags
+	// are bound to viper, so they can also be read with viper.Get* functions.
+	PersistentFlags func(flags *pflag.FlagSet)
+	// Commands are additional service-specific commands
+	Commands []CmdlineCommand
 }
 
+// CmdlineCommand is a service-specific command, e.g. to run a maintenance task with service configuration
+type CmdlineCommand struct {
+	// Use is a one-line usage message, the first word is a name of the command
+	Use   string
+	Short string
+	Long  string
+	// Args validates positional arguments, e.g. cobra.ExactArgs(1) (any arguments are accepted if nil)
+	Args cobra.PositionalArgs
+	// Flags allows to define command-specific flags
+	Flags func(flags *pflag.FlagSet)
+	// WithoutConfig commands do not have --deployment flag and do not load configuration (cfg is nil)
+	WithoutConfig bool
+	// Run executes the command with configuration loaded by CmdlineHandler.LoadConfig (it must be
+	// asserted to the configuration type, e.g. cfg.(*AppConfig)). Context is cancelled on SIGINT or SIGTERM.
+	// Returned error is reported and mapped to exit code (see ExitCode).
+	Run func(ctx context.Context, cfg any, args []string) error
+}
+
+// ConfigLoader returns a function to load configuration of type T with LoadConfigE,
+// it is intended to be used as CmdlineHandler.LoadConfig, e.g.
+//
+//	katapp.CmdlineExecute("myapp", short, long, &katapp.CmdlineHandler{
+//		Run:          run,
+//		EnvVarPrefix: "myapp",
+//		LoadConfig:   katapp.ConfigLoader[AppConfig]("myapp", nil),
+//		ConfigSchema: katapp.ConfigSchema[AppConfig],
+//	})
+func ConfigLoader[T any](
+	envVarPrefix string,
+	merger func() map[string]any,
+) func(deployment Deployment) (any, *ConfigProvenance, error) {
+	return func(deployment Deployment) (any, *ConfigProvenance, error) {
+		cfg, prov, err := LoadConfigE[T](envVarPrefix, deployment, merger)
+		if err != nil {
+			return nil, nil, err
+		}
+		return cfg, prov, nil
+	}
+}
+
+// CmdlineExecute executes command line of the service and exits with non-zero code if command fails
 func CmdlineExecute(name, short, long string, hdl *CmdlineHandler) {
+	rootCmd := newCmdlineRootCommand(name, short, long, hdl)
+	if err := rootCmd.Execute(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(ExitCode(err))
+	}
+}
+
+// newCmdlineRootCommand creates command tree of the service
+func newCmdlineRootCommand(name, short, long string, hdl *CmdlineHandler) *cobra.Command {
 	var deployment string
 	serverCmd := &cobra.Command{
 		Use:   "run --deployment={local|dev|prod|...}",
@@ -28,8 +135,13 @@ func CmdlineExecute(name, short, long string, hdl *CmdlineHandler) {
 		Long: "Run " + name + " and use configuration settings specified in 'deployment' flag. " +
 			"Name of deployment corresponds to filename in config directory, e.g. 'run --deployment=local' " +
 			"means that service will be started with config values loaded from configs/local.yaml file",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if hdl.RunE != nil {
+				cmd.SilenceUsage = true
+				return hdl.RunE(deployment)
+			}
 			hdl.Run(deployment)
+			return nil
 		},
 	}
 	serverCmd.Flags().StringVar(&deployment, "deployment", "",
@@ -37,13 +149,68 @@ func CmdlineExecute(name, short, long string, hdl *CmdlineHandler) {
 	_ = serverCmd.MarkFlagRequired("deployment")
 
 	var rootCmd = newCobraCmdlineCommand(name, short, long)
-	rootCmd.AddCommand(versionCmd)
+	// errors are reported by CmdlineExecute
+	rootCmd.SilenceErrors = true
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &ExitError{Code: ExitCodeUsage, Err: err}
+	})
+	if hdl.PersistentFlags != nil {
+		hdl.PersistentFlags(rootCmd.PersistentFlags())
+	}
+	rootCmd.AddCommand(newVersionCmd())
 	rootCmd.AddCommand(serverCmd)
+	rootCmd.AddCommand(newConfigCmd(hdl))
+	for _, c := range hdl.Commands {
+		rootCmd.AddCommand(newCustomCmd(c, hdl))
+	}
+	return &rootCmd
+}
 
-	if err := rootCmd.Execute(); err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+func newVersionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the version and build information",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			_, _ = fmt.Fprint(cmd.OutOrStdout(), ReadBuildInfo())
+		},
+	}
+}
+
+// newCustomCmd creates cobra command for service-specific command
+func newCustomCmd(c CmdlineCommand, hdl *CmdlineHandler) *cobra.Command {
+	var deployment string
+	cmd := &cobra.Command{
+		Use:   c.Use,
+		Short: c.Short,
+		Long:  c.Long,
+		Args:  c.Args,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			var cfg any
+			if !c.WithoutConfig {
+				if hdl.LoadConfig == nil {
+					return errors.New("configuration loader is not set (see CmdlineHandler.LoadConfig)")
+				}
+				var err error
+				if cfg, _, err = hdl.LoadConfig(Deployment{Name: deployment}); err != nil {
+					return err
+				}
+			}
+			ctx, stop := signal.NotifyContext(StartContext(slog.Default(), deployment), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return c.Run(ctx, cfg, args)
+		},
+	}
+	if !c.WithoutConfig {
+		cmd.Flags().StringVar(&deployment, "deployment", "",
+			"deployment environment, e.g. local, prod (it should match your config filename)")
+		_ = cmd.MarkFlagRequired("deployment")
+	}
+	if c.Flags != nil {
+		c.Flags(cmd.Flags())
 	}
+	return cmd
 }
 
 // newCobraCmdlineCommand is a helper function to add new command-line command and parameters
@@ -72,7 +239,8 @@ func copyViperToCobraFlags(cmd *cobra.Command) {
 		panic(err)
 	}
 	cmd.Flags().VisitAll(func(f *pflag.Flag) {
-		if viper.IsSet(f.Name) && viper.GetString(f.Name) != "" {
+		// flags explicitly set in command line take precedence over viper values
+		if !f.Changed && viper.IsSet(f.Name) && viper.GetString(f.Name) != "" {
 			if err := cmd.Flags().Set(f.Name, viper.GetString(f.Name)); err != nil {
 				panic(err)
 			}
@@ -0,0 +1,238 @@
+package katapp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// newConfigCmd creates "config" command with subcommands to manage configuration files
+func newConfigCmd(hdl *CmdlineHandler) *cobra.Command {
+	var keysFile, configDir string
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage configuration files",
+	}
+	configCmd.PersistentFlags().StringVar(&configDir, "config-dir", "",
+		"directory with configuration files (default \""+baseDir+"\")")
+	configCmd.PersistentFlags().StringVar(&keysFile, "keys-file", "",
+		"file with base64-encoded encryption keys, one per line, primary key first (by default keys are read from "+
+			ConfigKeysEnvVar(hdl.EnvVarPrefix)+" or "+ConfigKeysEnvVar(hdl.EnvVarPrefix)+"_FILE environment variable)")
+
+	loadKeyring := func() (*ConfigKeyring, error) {
+		if keysFile != "" {
+			return LoadConfigKeyringFile(keysFile)
+		}
+		return LoadConfigKeyring(hdl.EnvVarPrefix)
+	}
+
+	encryptCmd := &cobra.Command{
+		Use:   "encrypt [value]",
+		Short: "Encrypt configuration value with the primary key (value is read from stdin if omitted)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keyring, err := loadKeyring()
+			if err != nil {
+				return err
+			}
+			value, err := argOrStdin(cmd, args)
+			if err != nil {
+				return err
+			}
+			encrypted, err := keyring.Encrypt(value)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), encrypted)
+			return nil
+		},
+	}
+
+	decryptCmd := &cobra.Command{
+		Use:   "decrypt [value]",
+		Short: "Decrypt configuration value (value is read from stdin if omitted)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keyring, err := loadKeyring()
+			if err != nil {
+				return err
+			}
+			value, err := argOrStdin(cmd, args)
+			if err != nil {
+				return err
+			}
+			decrypted, err := keyring.Decrypt(value)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), decrypted)
+			return nil
+		},
+	}
+
+	rotateCmd := &cobra.Command{
+		Use:   "rotate file...",
+		Short: "Re-encrypt all encrypted values in configuration files with the primary key",
+		Long: "Re-encrypt all encrypted values in configuration files with the primary key. " +
+			"To rotate keys, put a new key first and keep the old keys after it, run this command " +
+			"for all configuration files and then remove the old keys.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keyring, err := loadKeyring()
+			if err != nil {
+				return err
+			}
+			for _, file := range args {
+				info, err := os.Stat(file)
+				if err != nil {
+					return err
+				}
+				content, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				updated, count, err := keyring.Reencrypt(string(content))
+				if err != nil {
+					return fmt.Errorf("failed to re-encrypt %s: %w", file, err)
+				}
+				if count > 0 {
+					if err := os.WriteFile(file, []byte(updated), info.Mode().Perm()); err != nil {
+						return err
+					}
+				}
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s: %d value(s) re-encrypted\n", file, count)
+			}
+			return nil
+		},
+	}
+
+	genkeyCmd := &cobra.Command{
+		Use:   "genkey",
+		Short: "Generate a new base64-encoded encryption key",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := GenerateConfigKey()
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), key)
+			return nil
+		},
+	}
+
+	loadConfig := func(deployment string) (any, error) {
+		if hdl.LoadConfig == nil {
+			return nil, errors.New("configuration loader is not set (see CmdlineHandler.LoadConfig)")
+		}
+		cfg, _, err := hdl.LoadConfig(Deployment{
+			Name:            deployment,
+			ConfigDir:       configDir,
+			CommonConfigDir: configDir,
+		})
+		return cfg, err
+	}
+
+	var deployment string
+	validateCmd := &cobra.Command{
+		Use:          "validate --deployment=<deployment>",
+		Short:        "Load and validate configuration of the deployment without starting the service",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := loadConfig(deployment); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "configuration of %s deployment is valid\n", deployment)
+			return nil
+		},
+	}
+	validateCmd.Flags().StringVar(&deployment, "deployment", "", "deployment to validate configuration of")
+	_ = validateCmd.MarkFlagRequired("deployment")
+
+	var format string
+	dumpCmd := &cobra.Command{
+		Use:          "dump --deployment=<deployment>",
+		Short:        "Print effective configuration of the deployment (secret values are redacted)",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(deployment)
+			if err != nil {
+				return err
+			}
+			return WriteConfig(cmd.OutOrStdout(), cfg, format)
+		},
+	}
+	dumpCmd.Flags().StringVar(&deployment, "deployment", "", "deployment to print configuration of")
+	dumpCmd.Flags().StringVarP(&format, "output", "o", "yaml", "output format (yaml or json)")
+	_ = dumpCmd.MarkFlagRequired("deployment")
+
+	var deployments []string
+	var exitCode bool
+	diffCmd := &cobra.Command{
+		Use:          "diff --deployment=<a> --deployment=<b>",
+		Short:        "Print differences between effective configurations of two deployments",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(deployments) != 2 {
+				return errors.New("exactly two --deployment flags are required")
+			}
+			a, err := loadConfig(deployments[0])
+			if err != nil {
+				return fmt.Errorf("%s: %w", deployments[0], err)
+			}
+			b, err := loadConfig(deployments[1])
+			if err != nil {
+				return fmt.Errorf("%s: %w", deployments[1], err)
+			}
+			diffs := DiffConfigs(a, b)
+			out := cmd.OutOrStdout()
+			_, _ = fmt.Fprintf(out, "--- %s\n+++ %s\n", deployments[0], deployments[1])
+			for _, d := range diffs {
+				_, _ = fmt.Fprintf(out, "%s: %s -> %s\n", d.Key, d.A, d.B)
+			}
+			if exitCode && len(diffs) > 0 {
+				return fmt.Errorf("configurations differ in %d key(s)", len(diffs))
+			}
+			return nil
+		},
+	}
+	diffCmd.Flags().StringArrayVar(&deployments, "deployment", nil, "deployments to compare (specify twice)")
+	diffCmd.Flags().BoolVar(&exitCode, "exit-code", false, "exit with non-zero status if configurations differ")
+	_ = diffCmd.MarkFlagRequired("deployment")
+
+	schemaCmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print JSON Schema of configuration files (e.g. to be used by YAML language server)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if hdl.ConfigSchema == nil {
+				return errors.New("configuration schema is not set (see CmdlineHandler.ConfigSchema)")
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(hdl.ConfigSchema())
+		},
+	}
+
+	configCmd.AddCommand(validateCmd, dumpCmd, diffCmd, schemaCmd, encryptCmd, decryptCmd, rotateCmd, genkeyCmd)
+	return configCmd
+}
+
+// argOrStdin returns the first argument or the first line read from stdin if there are no arguments
+func argOrStdin(cmd *cobra.Command, args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read value from stdin: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
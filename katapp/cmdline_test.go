@@ -2,9 +2,14 @@ package katapp
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -76,3 +81,219 @@ func TestCopyViperToCobraFlags(t *testing.T) {
 		t.Errorf("Expected flag value 'test-value', got '%s'", flagValue)
 	}
 }
+
+func TestConfigCmd_EncryptRotateDecrypt(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldKey, _ := GenerateConfigKey()
+	newKey, _ := GenerateConfigKey()
+	keysFile := filepath.Join(tmpDir, "keys")
+	configFile := filepath.Join(tmpDir, "prod.yaml")
+
+	runConfigCmd := func(stdin string, args ...string) string {
+		t.Helper()
+		cmd := newConfigCmd(&CmdlineHandler{EnvVarPrefix: "cmdtest"})
+		output := &bytes.Buffer{}
+		cmd.SetOut(output)
+		cmd.SetIn(strings.NewReader(stdin))
+		cmd.SetArgs(append(args, "--keys-file="+keysFile))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("config %v failed: %v", args, err)
+		}
+		return strings.TrimSpace(output.String())
+	}
+
+	_ = os.WriteFile(keysFile, []byte(oldKey+"\n"), 0600)
+	encrypted := runConfigCmd("s3cret\n", "encrypt")
+	_ = os.WriteFile(configFile, []byte("password: "+encrypted+"\n"), 0640)
+
+	_ = os.WriteFile(keysFile, []byte(newKey+"\n"+oldKey+"\n"), 0600)
+	if out := runConfigCmd("", "rotate", configFile); !strings.Contains(out, "1 value(s) re-encrypted") {
+		t.Errorf("Unexpected rotate output: %s", out)
+	}
+	content, _ := os.ReadFile(configFile)
+	rotated := strings.TrimPrefix(strings.TrimSpace(string(content)), "password: ")
+	if rotated == encrypted {
+		t.Errorf("Expected value to be re-encrypted")
+	}
+	if info, _ := os.Stat(configFile); info.Mode().Perm() != 0640 {
+		t.Errorf("Expected file permissions to be preserved, got %v", info.Mode().Perm())
+	}
+
+	_ = os.WriteFile(keysFile, []byte(newKey+"\n"), 0600)
+	if out := runConfigCmd("", "decrypt", rotated); out != "s3cret" {
+		t.Errorf("Expected decrypted value 's3cret', got '%s'", out)
+	}
+}
+
+type cmdlineTestConfig struct {
+	Server   ServerConfig   `mapstructure:"server"`
+	Database DatabaseConfig `mapstructure:"database"`
+}
+
+func runConfigTestCmd(t *testing.T, configDir string, args ...string) (string, error) {
+	t.Helper()
+	cmd := newConfigCmd(&CmdlineHandler{LoadConfig: ConfigLoader[cmdlineTestConfig]("cmdtest", nil)})
+	output := &bytes.Buffer{}
+	cmd.SetOut(output)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(append(args, "--config-dir="+configDir))
+	err := cmd.Execute()
+	return output.String(), err
+}
+
+func TestConfigCmd_ValidateDumpDiff(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(tmpDir, "common.yaml"),
+		[]byte("server:\n  port: 8080\ndatabase:\n  host: localhost\n  password: common-secret\n"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "local.yaml"), []byte("database:\n  user: dev\n"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "prod.yaml"),
+		[]byte("server:\n  port: 443\ndatabase:\n  host: db.prod\n  password: prod-secret\n"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "broken.yaml"), []byte("server:\n  port: 70000\n"), 0644)
+
+	if out, err := runConfigTestCmd(t, tmpDir, "validate", "--deployment=prod"); err != nil {
+		t.Errorf("Expected prod configuration to be valid, got %v", err)
+	} else if !strings.Contains(out, "is valid") {
+		t.Errorf("Unexpected validate output: %s", out)
+	}
+	if _, err := runConfigTestCmd(t, tmpDir, "validate", "--deployment=broken"); err == nil ||
+		!strings.Contains(err.Error(), "server.port") {
+		t.Errorf("Expected validation error for server.port, got %v", err)
+	}
+
+	out, err := runConfigTestCmd(t, tmpDir, "dump", "--deployment=prod")
+	if err != nil {
+		t.Fatalf("dump failed: %v", err)
+	}
+	if strings.Contains(out, "prod-secret") || !strings.Contains(out, "password: '***'") ||
+		!strings.Contains(out, "host: db.prod") || !strings.Contains(out, "port: 443") {
+		t.Errorf("Unexpected dump output: %s", out)
+	}
+	out, err = runConfigTestCmd(t, tmpDir, "dump", "--deployment=local", "-o", "json")
+	if err != nil {
+		t.Fatalf("dump failed: %v", err)
+	}
+	if !strings.Contains(out, `"user": "dev"`) || !strings.Contains(out, `"password": "***"`) {
+		t.Errorf("Unexpected json dump output: %s", out)
+	}
+
+	out, err = runConfigTestCmd(t, tmpDir, "diff", "--deployment=local", "--deployment=prod")
+	if err != nil {
+		t.Fatalf("diff failed: %v", err)
+	}
+	expected := "--- local\n+++ prod\n" +
+		"database.host: localhost -> db.prod\n" +
+		"database.password: *** -> ***\n" +
+		"database.user: dev -> \n" +
+		"server.port: 8080 -> 443\n"
+	if out != expected {
+		t.Errorf("Unexpected diff output:\n%s", out)
+	}
+	if _, err := runConfigTestCmd(t, tmpDir, "diff", "--deployment=local", "--deployment=prod", "--exit-code"); err == nil {
+		t.Errorf("Expected diff to fail with --exit-code")
+	}
+}
+
+func TestConfigCmd_Schema(t *testing.T) {
+	cmd := newConfigCmd(&CmdlineHandler{ConfigSchema: ConfigSchema[cmdlineTestConfig]})
+	output := &bytes.Buffer{}
+	cmd.SetOut(output)
+	cmd.SetArgs([]string{"schema"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("schema failed: %v", err)
+	}
+	if !strings.Contains(output.String(), `"$schema": "http://json-schema.org/draft-07/schema#"`) ||
+		!strings.Contains(output.String(), `"sslmode": {`) {
+		t.Errorf("Unexpected schema output: %s", output.String())
+	}
+}
+
+func TestCmdlineRootCommand_CustomCommandsAndExitCodes(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+	_ = os.MkdirAll(filepath.Join(tmpDir, "configs"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "configs", "local.yaml"), []byte("server:\n  port: 8080\n"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "configs", "broken.yaml"), []byte("server:\n  port: 0\n"), 0644)
+
+	var gotPort int
+	var gotArgs []string
+	var gotBatch int
+	handler := &CmdlineHandler{
+		RunE: func(deployment string) error {
+			return &ExitError{Code: 3, Err: errors.New("run failed")}
+		},
+		LoadConfig: ConfigLoader[cmdlineTestConfig]("cmdtest", nil),
+		PersistentFlags: func(flags *pflag.FlagSet) {
+			flags.Bool("verbose", false, "verbose output")
+		},
+		Commands: []CmdlineCommand{
+			{
+				Use:  "reindex <index>",
+				Args: cobra.ExactArgs(1),
+				Flags: func(flags *pflag.FlagSet) {
+					flags.IntVar(&gotBatch, "batch", 100, "batch size")
+				},
+				Run: func(ctx context.Context, cfg any, args []string) error {
+					Logger(ctx).Infof("reindexing")
+					gotPort = cfg.(*cmdlineTestConfig).Server.Port
+					gotArgs = args
+					return nil
+				},
+			},
+			{
+				Use:           "fail",
+				WithoutConfig: true,
+				Run: func(ctx context.Context, cfg any, args []string) error {
+					return errors.New("failed")
+				},
+			},
+		},
+	}
+	execute := func(args ...string) error {
+		cmd := newCmdlineRootCommand("test", "short desc", "long desc", handler)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		return cmd.Execute()
+	}
+
+	if err := execute("reindex", "users", "--deployment=local", "--batch=5", "--verbose"); err != nil {
+		t.Fatalf("reindex failed: %v", err)
+	}
+	if gotPort != 8080 || len(gotArgs) != 1 || gotArgs[0] != "users" || gotBatch != 5 {
+		t.Errorf("Unexpected reindex invocation: port=%d args=%v batch=%d", gotPort, gotArgs, gotBatch)
+	}
+
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"run", "--deployment=local"}, 3},
+		{[]string{"fail"}, ExitCodeError},
+		{[]string{"reindex", "users", "--deployment=broken"}, ExitCodeConfig},
+		{[]string{"reindex", "users", "--deployment=local", "--unknown"}, ExitCodeUsage},
+	}
+	for _, tt := range tests {
+		if code := ExitCode(execute(tt.args...)); code != tt.code {
+			t.Errorf("Expected exit code %d for %v, got %d", tt.code, tt.args, code)
+		}
+	}
+}
+
+func TestVersionCmd(t *testing.T) {
+	buildVersion, buildRevision, buildTime = "v1.2.3", "abc123", "2024-01-02T03:04:05Z"
+	defer func() {
+		buildVersion, buildRevision, buildTime = "", "", ""
+	}()
+	cmd := newVersionCmd()
+	output := &bytes.Buffer{}
+	cmd.SetOut(output)
+	cmd.SetArgs([]string{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("version failed: %v", err)
+	}
+	for _, expected := range []string{"version:     v1.2.3\n", "revision:    abc123", "build time:  2024-01-02T03:04:05Z\n", "go:"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected version output to contain %q, got:\n%s", expected, output.String())
+		}
+	}
+}
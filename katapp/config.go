@@ -4,59 +4,138 @@ import "time"
 
 type ServerConfig struct {
 	// Addr is a network address to listen on
-	Addr string
+	Addr string `doc:"Network address to listen on"`
 	// Port is a network port to listen on
-	Port int
-	// RequestDecompression is a type of decompression to be used on incoming requests (e.g. "gzip")
-	RequestDecompression string
+	Port int `validate:"required,min=1,max=65535" doc:"Network port to listen on"`
+	// RequestDecompression is a type of decompression to be used on incoming requests (e.g. "request-gzip")
+	RequestDecompression string `validate:"omitempty,oneof=request-gzip" doc:"Decompression of incoming requests"`
 	// ResponseCompression is a type of compression to be used on outgoing responses (e.g. "gzip")
-	ResponseCompression string
+	ResponseCompression string `validate:"omitempty,oneof=gzip" doc:"Compression of outgoing responses"`
 	// Domain
-	Domain string
+	Domain string `doc:"Domain name of the server"`
+	// DebugLogNetworks are networks (in CIDR notation) of callers trusted to enable debug logging
+	// of their requests with "X-Debug-Log: 1" header
+	DebugLogNetworks []string `validate:"omitempty,cidr" doc:"Networks (CIDR) of callers trusted to enable debug logging with X-Debug-Log header"`
+	// BaggageKeys are keys of W3C baggage entries accepted from callers, entries with other keys are dropped
+	// (baggage is added to request log records and propagated to outgoing requests)
+	BaggageKeys []string `doc:"Keys of baggage entries accepted from callers (other entries are dropped)"`
+	// MaxBodySize is a maximum size (in bytes) of JSON request body decoded by BindRequest of HTTP adapters,
+	// 1 MiB by default (larger bodies are rejected with 413 Request Entity Too Large)
+	MaxBodySize int64 `validate:"omitempty,min=1" doc:"Maximum size in bytes of JSON request body decoded by BindRequest (1 MiB by default)"`
+	// ErrorFormat is a format of error responses: "json" (default) or "problem" for RFC 9457
+	// "application/problem+json" responses
+	ErrorFormat string `validate:"omitempty,oneof=json problem" doc:"Format of error responses (json or problem for RFC 9457 problem+json)"`
+	// InternalErrors defines if text of internal errors (5xx) is reported to callers: "expose" (default)
+	// or "hide" to report generic message with error reference (full text is logged with the reference)
+	InternalErrors string `validate:"omitempty,oneof=expose hide" doc:"Reporting of internal error text (expose or hide for generic message with error reference)"`
 }
 
 type DatabaseConfig struct {
 	// File is a path to the database file (e.g. SQLite)
-	File string
+	File string `doc:"Path to the database file (e.g. SQLite)"`
 	// Host is a network address of the database server
-	Host string
+	Host string `doc:"Network address of the database server"`
 	// Port is a network port of the database server
-	Port int
+	Port int `validate:"omitempty,min=1,max=65535" doc:"Network port of the database server"`
 	// Name is a name of the database
-	Name string
+	Name string `doc:"Name of the database"`
 	// User is a name of the database user
-	User string
+	User string `doc:"Name of the database user"`
 	// Password is a password of the database user
-	Password string
+	Password string `secret:"true" doc:"Password of the database user"`
 	// Sslmode is a mode of SSL connection to the database (e.g. "disable")
-	Sslmode string
+	Sslmode string `validate:"omitempty,oneof=disable allow prefer require verify-ca verify-full" doc:"Mode of SSL connection to the database"`
 	// ConnectTimeout is a timeout for establishing a connection to the database
-	ConnectTimeout int
+	ConnectTimeout int `validate:"min=0" doc:"Timeout (in seconds) for establishing a connection to the database"`
 	// Migrations is a list of database migrations to be performed when database is connected
-	Migrations []DatabaseMigrationConfig
+	// (migrations of configuration layers are merged by service name)
+	Migrations []DatabaseMigrationConfig `mergekey:"service" doc:"Database migrations to be performed when database is connected"`
 	// Parameters provides additional connection parameters
-	Parameters map[string]string
-	Pool       DatabasePool
+	Parameters map[string]string `doc:"Additional connection parameters"`
+	Pool       DatabasePool      `doc:"Connection pool settings"`
 }
 
 // DatabaseMigrationConfig represents a database migration configuration
 type DatabaseMigrationConfig struct {
 	// Service is a name of the service that uses this migration
-	Service string
+	Service string `validate:"required" doc:"Name of the service that uses this migration"`
 	// Schema is a name of the database schema where the migration table is stored
-	Schema string
+	Schema string `doc:"Name of the database schema where the migration table is stored"`
 	// Path is a path to the directory with migration files
-	Path string
+	Path string `validate:"required" doc:"Path to the directory with migration files"`
 }
 
 type CacheConfig struct {
-	Type string
+	Type string `validate:"omitempty,oneof=none inmem" doc:"Type of the cache"`
 }
 
 type DatabasePool struct {
-	MaxConns        int
-	MinConns        int
-	MaxConnIdleTime time.Duration
-	MaxConnLifetime time.Duration
-	HealthPeriod    time.Duration
+	MaxConns        int           `validate:"omitempty,min=1,gtefield=MinConns" doc:"Maximum size of the pool"`
+	MinConns        int           `validate:"min=0" doc:"Minimum size of the pool"`
+	MaxConnIdleTime time.Duration `validate:"min=0s" doc:"Duration after which an idle connection is closed"`
+	MaxConnLifetime time.Duration `validate:"min=0s" doc:"Duration after which a connection is closed"`
+	HealthPeriod    time.Duration `validate:"min=0s" doc:"Duration between health checks of idle connections"`
+}
+
+// LoggingConfig configures application logger (see NewLogger)
+type LoggingConfig struct {
+	// Format is a format of log records ("text" by default)
+	Format string `validate:"omitempty,oneof=json text" doc:"Format of log records (text by default)"`
+	// Level is a minimum level of log records ("info" by default)
+	Level string `validate:"omitempty,oneof=debug info warn error" doc:"Minimum level of log records (info by default)"`
+	// Groups overrides minimum level for log groups (e.g. "katpg.DoMigration" or "KVTCache")
+	Groups []LogGroupConfig `mergekey:"group" doc:"Minimum levels of log records for specific log groups"`
+	// AddSource adds source code location to log records
+	AddSource bool `doc:"Add source code location to log records"`
+	// Output is a destination of log records ("stderr" by default)
+	Output string `validate:"omitempty,oneof=stdout stderr file" doc:"Destination of log records (stderr by default)"`
+	// File configures log file if Output is "file"
+	File LogFileConfig `doc:"Log file settings (if output is file)"`
+	// Sampling limits number of repeated log records
+	Sampling LogSamplingConfig `doc:"Sampling, deduplication and rate limiting of log records"`
+	// Redaction masks sensitive values in log records (see Redactor)
+	Redaction LogRedactionConfig `doc:"Masking of sensitive values in log records"`
+}
+
+// LogGroupConfig overrides minimum level for a log group
+type LogGroupConfig struct {
+	// Group is a name of log group, it also matches nested groups with dot-separated names
+	// (e.g. "katpg" matches "katpg.DoMigration")
+	Group string `validate:"required" doc:"Name of the log group (it also matches groups prefixed with this name and a dot)"`
+	// Level is a minimum level of log records of the group
+	Level string `validate:"required,oneof=debug info warn error" doc:"Minimum level of log records of the group"`
+}
+
+// LogFileConfig configures log file with size-based rotation
+type LogFileConfig struct {
+	// Path is a path to the log file
+	Path string `doc:"Path to the log file"`
+	// MaxSize is a size of the log file (in megabytes) to rotate it at (0 disables rotation)
+	MaxSize int `validate:"min=0" doc:"Size of the log file (in megabytes) to rotate it at (0 disables rotation)"`
+	// MaxBackups is a number of rotated log files to keep
+	MaxBackups int `validate:"min=0" doc:"Number of rotated log files to keep"`
+}
+
+// LogRedactionConfig configures masking of sensitive values in log records in addition to built-in
+// redaction of DefaultRedactedKeys, bearer tokens, card numbers and passwords in URLs
+type LogRedactionConfig struct {
+	// Keys are additional attribute keys with values to mask (matched case-insensitively as substrings)
+	Keys []string `doc:"Additional attribute keys with values to mask (matched case-insensitively as substrings)"`
+	// Patterns are regular expressions of additional values to mask in messages and attribute values
+	Patterns []string `doc:"Regular expressions of additional values to mask in messages and attribute values"`
+}
+
+// LogSamplingConfig configures sampling, deduplication and rate limiting of log records (see NewSamplingHandler)
+type LogSamplingConfig struct {
+	// Interval is a period sampling counters are reset after (1s by default)
+	Interval time.Duration `validate:"min=0s" doc:"Period sampling counters are reset after (1s by default)"`
+	// First is a number of records with the same level and message logged in every interval before
+	// sampling starts (0 disables sampling)
+	First int `validate:"min=0" doc:"Number of records with the same message logged in every interval before sampling starts (0 disables sampling)"`
+	// Thereafter logs every Thereafter-th record after First records (0 drops all of them)
+	Thereafter int `validate:"min=0" doc:"Every n-th record with the same message logged after the first ones (0 drops all of them)"`
+	// RateLimit is a maximum number of records logged per second (0 disables rate limiting)
+	RateLimit float64 `validate:"min=0" doc:"Maximum number of records logged per second (0 disables rate limiting)"`
+	// Burst is a maximum number of records logged at once above RateLimit (RateLimit by default)
+	Burst int `validate:"min=0" doc:"Maximum number of records logged at once above the rate limit (rate limit by default)"`
 }
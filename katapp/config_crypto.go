@@ -0,0 +1,183 @@
+package katapp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// encryptedValuePrefix is a prefix of encrypted configuration values. Complete format of the value is
+// "enc:v1:<key id>:<base64url(nonce + AES-256-GCM ciphertext)>".
+const encryptedValuePrefix = "enc:v1:"
+
+var encryptedValuePattern = regexp.MustCompile(`enc:v1:[0-9a-f]{8}:[A-Za-z0-9_-]+`)
+
+// ErrConfigKeyNotFound is returned when there is no key to decrypt a configuration value with
+var ErrConfigKeyNotFound = errors.New("configuration encryption key not found")
+
+// ConfigKeyring is a set of AES-256 keys to encrypt and decrypt configuration values. The first key
+// is a primary one and it is used to encrypt new values, all keys can be used to decrypt values.
+// To rotate keys, put a new key first, keep old keys after it and re-encrypt configuration files.
+type ConfigKeyring struct {
+	keys []configKey
+}
+
+type configKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewConfigKeyring creates keyring from 32-byte keys, the first key is a primary one
+func NewConfigKeyring(keys ...[]byte) (*ConfigKeyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrConfigKeyNotFound
+	}
+	kr := &ConfigKeyring{}
+	for _, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("configuration encryption key must be 32 bytes long, got %d", len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher: %w", err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher: %w", err)
+		}
+		sum := sha256.Sum256(key)
+		kr.keys = append(kr.keys, configKey{id: hex.EncodeToString(sum[:4]), aead: aead})
+	}
+	return kr, nil
+}
+
+// ParseConfigKeyring creates keyring from a comma (or whitespace) separated list of base64-encoded keys
+func ParseConfigKeyring(s string) (*ConfigKeyring, error) {
+	var keys [][]byte
+	for _, encoded := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\r' || r == '\t'
+	}) {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode configuration encryption key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return NewConfigKeyring(keys...)
+}
+
+// ConfigKeysEnvVar returns name of environment variable with configuration encryption keys,
+// e.g. "MYAPP_CONFIG_KEYS" for "myapp" prefix. Keys can also be stored in a file referenced by
+// environment variable with "_FILE" suffix, e.g. "MYAPP_CONFIG_KEYS_FILE".
+func ConfigKeysEnvVar(envVarPrefix string) string {
+	return configEnvVarName(envVarPrefix, "config.keys")
+}
+
+// LoadConfigKeyring loads keyring from environment variable or key file (see ConfigKeysEnvVar)
+func LoadConfigKeyring(envVarPrefix string) (*ConfigKeyring, error) {
+	name := ConfigKeysEnvVar(envVarPrefix)
+	if keys := os.Getenv(name); keys != "" {
+		return ParseConfigKeyring(keys)
+	}
+	if file := os.Getenv(name + "_FILE"); file != "" {
+		return LoadConfigKeyringFile(file)
+	}
+	return nil, fmt.Errorf("%w: neither %s nor %s_FILE environment variable is set",
+		ErrConfigKeyNotFound, name, name)
+}
+
+// LoadConfigKeyringFile loads keyring from a file with base64-encoded keys (one per line)
+func LoadConfigKeyringFile(file string) (*ConfigKeyring, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration keys file %s: %w", file, err)
+	}
+	return ParseConfigKeyring(string(content))
+}
+
+// GenerateConfigKey generates a new random base64-encoded key
+func GenerateConfigKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// IsEncryptedValue checks if configuration value is encrypted
+func IsEncryptedValue(value string) bool {
+	return strings.HasPrefix(value, encryptedValuePrefix)
+}
+
+// Encrypt encrypts value with the primary key
+func (kr *ConfigKeyring) Encrypt(plaintext string) (string, error) {
+	key := kr.keys[0]
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	header := encryptedValuePrefix + key.id + ":"
+	sealed := key.aead.Seal(nonce, nonce, []byte(plaintext), []byte(header))
+	return header + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts value previously encrypted with any key of the keyring
+func (kr *ConfigKeyring) Decrypt(value string) (string, error) {
+	if !IsEncryptedValue(value) {
+		return "", errors.New("value is not encrypted")
+	}
+	id, payload, ok := strings.Cut(strings.TrimPrefix(value, encryptedValuePrefix), ":")
+	if !ok {
+		return "", errors.New("malformed encrypted value")
+	}
+	for _, key := range kr.keys {
+		if key.id != id {
+			continue
+		}
+		sealed, err := base64.RawURLEncoding.DecodeString(payload)
+		if err != nil || len(sealed) < key.aead.NonceSize() {
+			return "", errors.New("malformed encrypted value")
+		}
+		nonce, ciphertext := sealed[:key.aead.NonceSize()], sealed[key.aead.NonceSize():]
+		plaintext, err := key.aead.Open(nil, nonce, ciphertext, []byte(encryptedValuePrefix+id+":"))
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt value with key %s: %w", id, err)
+		}
+		return string(plaintext), nil
+	}
+	return "", fmt.Errorf("%w: no key with id %s", ErrConfigKeyNotFound, id)
+}
+
+// Reencrypt decrypts all encrypted values in the text (e.g. content of configuration file) and
+// encrypts them again with the primary key. It returns updated text and number of re-encrypted values.
+func (kr *ConfigKeyring) Reencrypt(text string) (string, int, error) {
+	var firstErr error
+	count := 0
+	result := encryptedValuePattern.ReplaceAllStringFunc(text, func(value string) string {
+		if firstErr != nil || strings.HasPrefix(value, encryptedValuePrefix+kr.keys[0].id+":") {
+			return value
+		}
+		plaintext, err := kr.Decrypt(value)
+		if err == nil {
+			value, err = kr.Encrypt(plaintext)
+		}
+		if err != nil {
+			firstErr = err
+			return value
+		}
+		count++
+		return value
+	})
+	if firstErr != nil {
+		return "", 0, firstErr
+	}
+	return result, count, nil
+}
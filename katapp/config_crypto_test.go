@@ -0,0 +1,91 @@
+package katapp_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeyring(t *testing.T) (*katapp.ConfigKeyring, string) {
+	t.Helper()
+	key, err := katapp.GenerateConfigKey()
+	require.NoError(t, err)
+	keyring, err := katapp.ParseConfigKeyring(key)
+	require.NoError(t, err)
+	return keyring, key
+}
+
+func TestConfigKeyring_EncryptDecrypt(t *testing.T) {
+	keyring, _ := newTestKeyring(t)
+	encrypted, err := keyring.Encrypt("pa$$word")
+	require.NoError(t, err)
+	assert.True(t, katapp.IsEncryptedValue(encrypted))
+	assert.NotContains(t, encrypted, "pa$$word")
+
+	decrypted, err := keyring.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "pa$$word", decrypted)
+
+	tampered := encrypted[:len(encrypted)-2] + "AA"
+	_, err = keyring.Decrypt(tampered)
+	assert.Error(t, err)
+
+	otherKeyring, _ := newTestKeyring(t)
+	_, err = otherKeyring.Decrypt(encrypted)
+	assert.ErrorIs(t, err, katapp.ErrConfigKeyNotFound)
+}
+
+func TestConfigKeyring_Reencrypt(t *testing.T) {
+	oldKeyring, oldKey := newTestKeyring(t)
+	_, newKey := newTestKeyring(t)
+	encrypted, err := oldKeyring.Encrypt("secret")
+	require.NoError(t, err)
+
+	rotatedKeyring, err := katapp.ParseConfigKeyring(newKey + "," + oldKey)
+	require.NoError(t, err)
+	content := "database:\n  password: " + encrypted + "\n  user: app\n"
+	updated, count, err := rotatedKeyring.Reencrypt(content)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NotContains(t, updated, encrypted)
+	assert.Contains(t, updated, "  user: app\n")
+
+	newKeyring, err := katapp.ParseConfigKeyring(newKey)
+	require.NoError(t, err)
+	reencrypted := strings.TrimSpace(strings.Split(strings.Split(updated, "password: ")[1], "\n")[0])
+	decrypted, err := newKeyring.Decrypt(reencrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", decrypted)
+
+	_, count, err = rotatedKeyring.Reencrypt(updated)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestLoad_DecryptsEncryptedValues(t *testing.T) {
+	keyring, key := newTestKeyring(t)
+	encrypted, err := keyring.Encrypt("${not-expanded}")
+	require.NoError(t, err)
+
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "local.yaml"),
+		[]byte("database:\n  password: "+encrypted+"\n"), 0644))
+	type Config struct {
+		Database katapp.DatabaseConfig `mapstructure:"database"`
+	}
+	deployment := katapp.Deployment{Name: "local", ConfigDir: tmpDir, CommonConfigDir: tmpDir}
+
+	t.Setenv(katapp.ConfigKeysEnvVar("cryptotest"), key)
+	cfg, _, err := katapp.LoadConfigE[Config]("cryptotest", deployment, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "${not-expanded}", cfg.Database.Password)
+
+	t.Setenv(katapp.ConfigKeysEnvVar("cryptotest"), "")
+	_, _, err = katapp.LoadConfigE[Config]("cryptotest", deployment, nil)
+	assert.ErrorIs(t, err, katapp.ErrConfigKeyNotFound)
+}
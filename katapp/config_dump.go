@@ -0,0 +1,192 @@
+package katapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// redactedValue is a placeholder printed instead of values of fields tagged with `secret:"true"`
+const redactedValue = "***"
+
+// secretConfigValue wraps value of a secret field in configuration tree. It is compared by its real value,
+// but it is always marshalled as redactedValue (or as empty string if secret is not set).
+type secretConfigValue struct {
+	value any
+}
+
+func (s secretConfigValue) String() string {
+	if isEmptyValue(reflect.ValueOf(s.value)) {
+		return ""
+	}
+	return redactedValue
+}
+
+func (s secretConfigValue) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+func (s secretConfigValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// WriteConfig writes effective configuration (as decoded into cfg struct) to w in "yaml" or "json" format.
+// Keys are named the same way as in configuration files and values of fields tagged with `secret:"true"`
+// are redacted, e.g.
+//
+//	Password string `secret:"true"`
+func WriteConfig(w io.Writer, cfg any, format string) error {
+	tree := configTree(reflect.ValueOf(cfg))
+	switch format {
+	case "yaml", "":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(tree); err != nil {
+			return fmt.Errorf("failed to encode configuration: %w", err)
+		}
+		return enc.Close()
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(tree); err != nil {
+			return fmt.Errorf("failed to encode configuration: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported configuration format %q (expected yaml or json)", format)
+	}
+}
+
+// ConfigDifference is a configuration key with different effective values in two configurations
+type ConfigDifference struct {
+	Key string
+	// A and B are printable values of the key, redacted for secrets and "<not set>" for missing keys
+	A, B string
+}
+
+// DiffConfigs compares effective values of two configurations (e.g. decoded for different deployments)
+// and returns keys with different values, sorted by key. Secret values are compared, but never revealed.
+func DiffConfigs(a, b any) []ConfigDifference {
+	aValues := make(map[string]any)
+	bValues := make(map[string]any)
+	flattenConfigTree("", configTree(reflect.ValueOf(a)), aValues)
+	flattenConfigTree("", configTree(reflect.ValueOf(b)), bValues)
+
+	keys := make([]string, 0, len(aValues)+len(bValues))
+	for k := range aValues {
+		keys = append(keys, k)
+	}
+	for k := range bValues {
+		if _, ok := aValues[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var diffs []ConfigDifference
+	for _, k := range keys {
+		av, aok := aValues[k]
+		bv, bok := bValues[k]
+		if aok && bok && reflect.DeepEqual(av, bv) {
+			continue
+		}
+		diffs = append(diffs, ConfigDifference{Key: k, A: printableConfigValue(av, aok), B: printableConfigValue(bv, bok)})
+	}
+	return diffs
+}
+
+func printableConfigValue(v any, ok bool) string {
+	if !ok || v == nil {
+		return "<not set>"
+	}
+	return fmt.Sprint(v)
+}
+
+// configTree converts configuration struct into a tree of maps, slices and scalar values
+// with keys named the same way as in configuration files
+func configTree(rv reflect.Value) any {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Type() {
+	case durationType:
+		return rv.Interface().(time.Duration).String()
+	case timeType:
+		return rv.Interface().(time.Time).Format(time.RFC3339Nano)
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		m := make(map[string]any)
+		addStructToConfigTree(rv, m)
+		return m
+	case reflect.Map:
+		m := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[strings.ToLower(fmt.Sprint(iter.Key().Interface()))] = configTree(iter.Value())
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+		s := make([]any, rv.Len())
+		for i := range s {
+			s[i] = configTree(rv.Index(i))
+		}
+		return s
+	default:
+		return rv.Interface()
+	}
+}
+
+func addStructToConfigTree(rv reflect.Value, m map[string]any) {
+	for i := 0; i < rv.NumField(); i++ {
+		sf := rv.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := configFieldName(sf)
+		switch {
+		case name == "-":
+			continue
+		case sf.Tag.Get("secret") == "true":
+			m[name] = secretConfigValue{value: rv.Field(i).Interface()}
+		case name == "":
+			fv := rv.Field(i)
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				addStructToConfigTree(fv, m)
+			}
+		default:
+			m[name] = configTree(rv.Field(i))
+		}
+	}
+}
+
+// flattenConfigTree collects leaf values of configuration tree with keys such as "database.migrations[0].path"
+func flattenConfigTree(key string, tree any, out map[string]any) {
+	switch t := tree.(type) {
+	case map[string]any:
+		for k, v := range t {
+			flattenConfigTree(joinFieldPath(key, k), v, out)
+		}
+	case []any:
+		for i, v := range t {
+			flattenConfigTree(fmt.Sprintf("%s[%d]", key, i), v, out)
+		}
+	default:
+		out[key] = t
+	}
+}
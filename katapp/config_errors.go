@@ -0,0 +1,36 @@
+package katapp
+
+import "fmt"
+
+// ConfigFileError is reported when configuration file of a specific layer cannot be found, read or parsed
+type ConfigFileError struct {
+	// Layer is a configuration layer of the file (common, deployment or overlay)
+	Layer ConfigLayer
+	// File is a path to the configuration file (without extension if file was not found)
+	File string
+	Err  error
+}
+
+func (e *ConfigFileError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("failed to load %s configuration file: %v", e.Layer, e.Err)
+	}
+	return fmt.Sprintf("failed to load %s configuration file %s: %v", e.Layer, e.File, e.Err)
+}
+
+func (e *ConfigFileError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigDecodeError is reported when merged configuration cannot be decoded into the target struct
+type ConfigDecodeError struct {
+	Err error
+}
+
+func (e *ConfigDecodeError) Error() string {
+	return fmt.Sprintf("error parsing configuration: %v", e.Err)
+}
+
+func (e *ConfigDecodeError) Unwrap() error {
+	return e.Err
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 
@@ -14,85 +15,159 @@ import (
 
 const baseDir = "./configs"
 
+// configFileExts are supported extensions of configuration files, in order of lookup
+var configFileExts = []string{"yaml", "yml", "json", "toml"}
+
 type Deployment struct {
 	Name            string
 	ConfigDir       string
 	CommonConfigDir string
+	// Overlays are additional configuration files merged (in order) on top of common and
+	// deployment configuration files, e.g. "prod.eu-west" region settings or gitignored "local.override"
+	Overlays []ConfigOverlay
+}
+
+// ConfigOverlay is an additional configuration file layer of a deployment
+type ConfigOverlay struct {
+	// Name is a name of configuration file without extension, e.g. "prod.eu-west"
+	Name string
+	// Dir is a directory of configuration file (deployment configuration directory is used if empty)
+	Dir string
+	// Optional overlay is skipped if its configuration file does not exist
+	Optional bool
+}
+
+// configFileLayer is a configuration file to be loaded as a part of deployment configuration
+type configFileLayer struct {
+	layer    ConfigLayer
+	dir      string
+	name     string
+	optional bool
+}
+
+// configLayers returns configuration file layers of the deployment in order of increasing precedence
+func (d Deployment) configLayers() []configFileLayer {
+	commonBaseDir, deploymentBaseDir := d.configDirs()
+	layers := []configFileLayer{
+		{layer: ConfigLayerCommon, dir: commonBaseDir, name: "common", optional: true},
+		{layer: ConfigLayerDeployment, dir: deploymentBaseDir, name: d.Name},
+	}
+	for _, o := range d.Overlays {
+		dir := o.Dir
+		if dir == "" {
+			dir = deploymentBaseDir
+		}
+		layers = append(layers, configFileLayer{layer: ConfigLayerOverlay, dir: dir, name: o.Name, optional: o.Optional})
+	}
+	return layers
+}
+
+// configDirs returns directories to load common and deployment-specific configuration files from
+func (d Deployment) configDirs() (commonBaseDir string, deploymentBaseDir string) {
+	if d.CommonConfigDir == "" {
+		commonBaseDir = baseDir
+	} else {
+		commonBaseDir = d.CommonConfigDir
+	}
+	if d.ConfigDir == "" {
+		deploymentBaseDir = baseDir
+	} else {
+		deploymentBaseDir = d.ConfigDir
+	}
+	return commonBaseDir, deploymentBaseDir
 }
 
 func LoadConfig[T any](envVarPrefix string, deployment Deployment) *T {
 	return LoadConfigWithMerger[T](envVarPrefix, deployment, nil)
 }
 
-// LoadConfigWithMerger loads configuration from yaml files and environment variables.
-// It loads common configuration first and then overrides it with deployment-specific configuration.
+// configFatalf reports configuration that cannot be loaded and exits (tests replace it to panic instead)
+var configFatalf = log.Fatalf
+
+// LoadConfigWithMerger loads configuration from yaml, json or toml files and environment variables.
+// It loads common configuration first and then overrides it with deployment-specific configuration
+// and deployment overlays (if any).
 // If commonDir is provided (not null), it will be used as a base directory for common configuration, but
 // deployment-specific configuration will still be loaded from the default base directory.
+// It exits the application if configuration cannot be loaded, use LoadConfigE to handle errors instead.
 func LoadConfigWithMerger[T any](
 	envVarPrefix string,
 	deployment Deployment,
 	merger func() map[string]any,
 ) *T {
-	{
-		var commonBaseDir string
-		if deployment.CommonConfigDir == "" {
-			commonBaseDir = baseDir
-		} else {
-			commonBaseDir = deployment.CommonConfigDir
-		}
-
-		var deploymentBaseDir string
-		if deployment.ConfigDir == "" {
-			deploymentBaseDir = baseDir
-		} else {
-			deploymentBaseDir = deployment.ConfigDir
-		}
+	cfg, _, err := LoadConfigE[T](envVarPrefix, deployment, merger)
+	if err != nil {
+		configFatalf("%v", err)
+	}
+	return cfg
+}
 
-		v := viper.New()
-		v.SetEnvPrefix(envVarPrefix)
-		v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-		v.AutomaticEnv()
-		v.SetConfigType("yaml")
-
-		// 1) Load "common" (optional)
-		v.SetConfigName("common")
-		v.AddConfigPath(commonBaseDir)
-		if err := v.ReadInConfig(); err != nil {
-			var nf viper.ConfigFileNotFoundError
-			if !errors.As(err, &nf) {
-				log.Fatalf("failed to load common configuration file: %v", err)
+// LoadConfigE is a non-fatal version of LoadConfigWithMerger (merger is optional and can be nil).
+// Decoded configuration is validated against `validate` struct tags (see Validate).
+// It returns loaded configuration together with provenance of every configuration key, or one of
+// *ConfigFileError, *ConfigDecodeError, *ConfigValidationError errors.
+func LoadConfigE[T any](
+	envVarPrefix string,
+	deployment Deployment,
+	merger func() map[string]any,
+) (*T, *ConfigProvenance, error) {
+	v := viper.New()
+	v.SetEnvPrefix(envVarPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	prov := newConfigProvenance(envVarPrefix)
+
+	// 1) Load "common" (optional), deployment-specific and overlay configuration files
+	// and merge them in order (lists with `mergekey` tag are merged by key, see mergeConfigMaps)
+	settings := make(map[string]any)
+	for _, l := range deployment.configLayers() {
+		file, err := findConfigFile(l.dir, l.name)
+		if err != nil {
+			if l.optional && errors.Is(err, os.ErrNotExist) {
+				continue
 			}
+			return nil, nil, &ConfigFileError{Layer: l.layer, File: filepath.Join(l.dir, l.name), Err: err}
 		}
-
-		// 2) Merge deployment-specific overrides
-		v.SetConfigName(deployment.Name)
-		v.AddConfigPath(deploymentBaseDir)
-		if err := v.MergeInConfig(); err != nil {
-			log.Fatalf("failed to load deployment configuration file: %v", err)
+		layerSettings, err := readConfigFile(file)
+		if err != nil {
+			return nil, nil, &ConfigFileError{Layer: l.layer, File: file, Err: err}
 		}
-
-		// 3) Apply merger-provided overrides (highest precedence via v.Set)
-		if merger != nil {
-			if merged := (merger)(); merged != nil {
-				applyMergeAsOverrides(v, envVarPrefix, merged)
-			}
+		if err := prov.addFile(l.layer, file, layerSettings); err != nil {
+			return nil, nil, &ConfigFileError{Layer: l.layer, File: file, Err: err}
 		}
+		mergeConfigMaps(settings, layerSettings, reflect.TypeFor[T]())
+	}
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, nil, &ConfigDecodeError{Err: err}
+	}
+	prov.addEnv()
 
-		// 4) Decode into the target struct with env-var expansion support
-		var cfg T
-		if err := v.Unmarshal(&cfg, decoderWithEnvVariablesSupport()); err != nil {
-			log.Fatalf("error parsing configuration: %v", err)
+	// 2) Apply merger-provided overrides (highest precedence via v.Set)
+	if merger != nil {
+		if merged := (merger)(); merged != nil {
+			applyMergeAsOverrides(v, envVarPrefix, merged, prov)
 		}
+	}
 
-		return &cfg
+	// 3) Decode into the target struct with env-var expansion support
+	var cfg T
+	if err := v.Unmarshal(&cfg, decoderWithEnvVariablesSupport(envVarPrefix)); err != nil {
+		return nil, nil, &ConfigDecodeError{Err: err}
 	}
+
+	// 4) Validate decoded values against `validate` struct tags
+	if err := validateConfig(&cfg, prov); err != nil {
+		return nil, nil, err
+	}
+
+	return &cfg, prov, nil
 }
 
-func applyMergeAsOverrides(v *viper.Viper, envPrefix string, m map[string]any) {
-	flattenAndSetWithNorm(v, "", envPrefix, m)
+func applyMergeAsOverrides(v *viper.Viper, envPrefix string, m map[string]any, prov *ConfigProvenance) {
+	flattenAndSetWithNorm(v, "", envPrefix, m, prov)
 }
 
-func flattenAndSetWithNorm(v *viper.Viper, prefix, envPrefix string, val any) {
+func flattenAndSetWithNorm(v *viper.Viper, prefix, envPrefix string, val any, prov *ConfigProvenance) {
 	switch t := val.(type) {
 	case map[string]any:
 		for k, v2 := range t {
@@ -100,7 +175,7 @@ func flattenAndSetWithNorm(v *viper.Viper, prefix, envPrefix string, val any) {
 			if prefix != "" {
 				key = prefix + "." + k
 			}
-			flattenAndSetWithNorm(v, key, envPrefix, v2)
+			flattenAndSetWithNorm(v, key, envPrefix, v2, prov)
 		}
 	default:
 		// If this is a leaf and the key looks env-like, normalize it.
@@ -109,6 +184,7 @@ func flattenAndSetWithNorm(v *viper.Viper, prefix, envPrefix string, val any) {
 			key = normalizeKey(key, envPrefix)
 		}
 		v.Set(key, t) // Set = highest precedence: Set > env > config
+		prov.add(key, ConfigValueSource{Layer: ConfigLayerMerger, Value: t})
 	}
 }
 
@@ -148,39 +224,30 @@ func loadFromYaml[T any](opt loadOptions, cfg *T) error {
 	if err := v.ReadInConfig(); err != nil {
 		return fmt.Errorf("error reading configuration file: %w", err)
 	}
-	err := v.Unmarshal(cfg, decoderWithEnvVariablesSupport())
+	err := v.Unmarshal(cfg, decoderWithEnvVariablesSupport(opt.EnvVarPrefix))
 	if err != nil {
 		return fmt.Errorf("error parsing configuration file: %w", err)
 	}
 	return nil
 }
 
-// decoderWithEnvVariablesSupport allows us to resolve values containing environment variables,
-// e.g. in yaml file you should be able to use constructions such as:
+// decoderWithEnvVariablesSupport allows us to resolve values containing environment variables
+// and secret references (see RegisterSecretResolver), e.g. in yaml file you should be able to use
+// constructions such as:
 //
 //	file_path: "${HOME}/notes.txt"
-func decoderWithEnvVariablesSupport() viper.DecoderConfigOption {
+//	password: "${file:/run/secrets/db_password}"
+//	api_key: "enc:v1:..."
+//
+// Encrypted values (see ConfigKeyring) are decrypted with keys found by LoadConfigKeyring(envVarPrefix).
+// References are resolved before any other conversion and only once per decoding.
+func decoderWithEnvVariablesSupport(envVarPrefix string) viper.DecoderConfigOption {
+	res := newSecretResolution(envVarPrefix)
 	return func(c *mapstructure.DecoderConfig) {
 		c.DecodeHook = mapstructure.ComposeDecodeHookFunc(
+			res.replaceReferencesHookFunc,
 			c.DecodeHook,
 			mapstructure.StringToSliceHookFunc(","),
-			replaceEnvVarsHookFunc,
 		)
 	}
 }
-
-func replaceEnvVars(value string) string {
-	return os.ExpandEnv(value)
-}
-
-func replaceEnvVarsHookFunc(
-	f reflect.Type,
-	t reflect.Type,
-	data interface{},
-) (interface{}, error) {
-	if f.Kind() != reflect.String || t.Kind() != reflect.String {
-		return data, nil
-	}
-
-	return replaceEnvVars(data.(string)), nil
-}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func createTempYamlFile(t *testing.T, content string) string {
@@ -177,3 +178,165 @@ func TestLoad_MissingConfigDir(t *testing.T) {
 		katapp.LoadConfig[Config]("", deployment)
 	})
 }
+
+func TestLoadConfigE_ProvenanceAndExplain(t *testing.T) {
+	commonContent := `
+server:
+  addr: "0.0.0.0"
+  port: 8080
+`
+	deploymentContent := `
+server:
+  port: 9090
+database:
+  user: "admin"
+`
+	tmpDir := t.TempDir()
+	commonFile := filepath.Join(tmpDir, "common.yaml")
+	deploymentFile := filepath.Join(tmpDir, "deployment.yaml")
+	_ = os.WriteFile(commonFile, []byte(commonContent), 0644)
+	_ = os.WriteFile(deploymentFile, []byte(deploymentContent), 0644)
+	t.Setenv("PROVAPP_SERVER_ADDR", "127.0.0.1")
+
+	deployment := katapp.Deployment{
+		Name:            "deployment",
+		ConfigDir:       tmpDir,
+		CommonConfigDir: tmpDir,
+	}
+
+	type Config struct {
+		Server   katapp.ServerConfig   `mapstructure:"server"`
+		Database katapp.DatabaseConfig `mapstructure:"database"`
+	}
+
+	cfg, prov, err := katapp.LoadConfigE[Config]("provapp", deployment, func() map[string]any {
+		return map[string]any{"database": map[string]any{"user": "merged"}}
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", cfg.Server.Addr)
+	assert.Equal(t, 9090, cfg.Server.Port)
+	assert.Equal(t, "merged", cfg.Database.User)
+
+	src, ok := prov.Source("server.port")
+	assert.True(t, ok)
+	assert.Equal(t, katapp.ConfigLayerDeployment, src.Layer)
+	assert.Equal(t, deploymentFile, src.File)
+	assert.Equal(t, 3, src.Line)
+
+	src, _ = prov.Source("server.addr")
+	assert.Equal(t, katapp.ConfigLayerEnv, src.Layer)
+	assert.Equal(t, "PROVAPP_SERVER_ADDR", src.EnvVar)
+
+	src, _ = prov.Source("database.user")
+	assert.Equal(t, katapp.ConfigLayerMerger, src.Layer)
+	assert.Len(t, prov.Sources("database.user"), 2)
+
+	assert.Equal(t,
+		"server.port = 9090 (from "+deploymentFile+":3, deployment)\n"+
+			"  overrides 8080 (from "+commonFile+":4, common)",
+		prov.Explain("server.port"))
+	assert.Contains(t, prov.Explain("server"), "server.addr = 127.0.0.1 (from env PROVAPP_SERVER_ADDR, env)")
+	assert.Equal(t, "server.domain is not set in any configuration source", prov.Explain("server.domain"))
+}
+
+func TestLoadConfigE_ReturnsTypedErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	type Config struct {
+		Server katapp.ServerConfig `mapstructure:"server"`
+	}
+	deployment := katapp.Deployment{
+		Name:            "deployment",
+		ConfigDir:       tmpDir,
+		CommonConfigDir: tmpDir,
+	}
+
+	// Missing deployment file
+	_, _, err := katapp.LoadConfigE[Config]("", deployment, nil)
+	var fileErr *katapp.ConfigFileError
+	assert.ErrorAs(t, err, &fileErr)
+	assert.Equal(t, katapp.ConfigLayerDeployment, fileErr.Layer)
+
+	// Value that cannot be decoded
+	_ = os.WriteFile(filepath.Join(tmpDir, "deployment.yaml"), []byte("server:\n  port: \"invalid\"\n"), 0644)
+	_, _, err = katapp.LoadConfigE[Config]("", deployment, nil)
+	var decodeErr *katapp.ConfigDecodeError
+	assert.ErrorAs(t, err, &decodeErr)
+
+	// Value that is out of range
+	_ = os.WriteFile(filepath.Join(tmpDir, "deployment.yaml"), []byte("server:\n  port: 70000\n"), 0644)
+	_, _, err = katapp.LoadConfigE[Config]("", deployment, nil)
+	var validationErr *katapp.ConfigValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr.Violations, 1)
+	assert.Equal(t, "server.port", validationErr.Violations[0].Field)
+	assert.Equal(t, filepath.Join(tmpDir, "deployment.yaml")+":2", validationErr.Violations[0].Source)
+}
+
+func TestLoadConfigE_OverlaysAndFormats(t *testing.T) {
+	tmpDir := t.TempDir()
+	overrideDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(tmpDir, "common.json"), []byte(`{
+  "server": {"addr": "0.0.0.0", "port": 8080},
+  "database": {
+    "host": "localhost",
+    "migrations": [
+      {"service": "users", "schema": "public", "path": "./migrations/users"},
+      {"service": "orders", "schema": "public", "path": "./migrations/orders"}
+    ]
+  }
+}`), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "prod.yaml"), []byte(`
+database:
+  host: db.prod
+  migrations:
+    - service: orders
+      schema: orders
+    - service: billing
+      path: ./migrations/billing
+`), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "prod.eu-west.toml"), []byte(`
+[database]
+host = "db.eu-west.prod"
+`), 0644)
+	_ = os.WriteFile(filepath.Join(overrideDir, "local.override.yaml"), []byte("server:\n  port: 9090\n"), 0644)
+
+	type Config struct {
+		Server   katapp.ServerConfig   `mapstructure:"server"`
+		Database katapp.DatabaseConfig `mapstructure:"database"`
+	}
+	deployment := katapp.Deployment{
+		Name:            "prod",
+		ConfigDir:       tmpDir,
+		CommonConfigDir: tmpDir,
+		Overlays: []katapp.ConfigOverlay{
+			{Name: "prod.eu-west"},
+			{Name: "prod.us-east", Optional: true},
+			{Name: "local.override", Dir: overrideDir, Optional: true},
+		},
+	}
+	cfg, prov, err := katapp.LoadConfigE[Config]("", deployment, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "0.0.0.0", cfg.Server.Addr)
+	assert.Equal(t, 9090, cfg.Server.Port)
+	assert.Equal(t, "db.eu-west.prod", cfg.Database.Host)
+	assert.Equal(t, []katapp.DatabaseMigrationConfig{
+		{Service: "users", Schema: "public", Path: "./migrations/users"},
+		{Service: "orders", Schema: "orders", Path: "./migrations/orders"},
+		{Service: "billing", Path: "./migrations/billing"},
+	}, cfg.Database.Migrations)
+
+	src, _ := prov.Source("database.host")
+	assert.Equal(t, katapp.ConfigLayerOverlay, src.Layer)
+	assert.Equal(t, filepath.Join(tmpDir, "prod.eu-west.toml"), src.File)
+	src, _ = prov.Source("server.addr")
+	assert.Equal(t, filepath.Join(tmpDir, "common.json")+":2", src.String())
+
+	// Required overlay is missing
+	deployment.Overlays = append(deployment.Overlays, katapp.ConfigOverlay{Name: "prod.missing"})
+	_, _, err = katapp.LoadConfigE[Config]("", deployment, nil)
+	var fileErr *katapp.ConfigFileError
+	require.ErrorAs(t, err, &fileErr)
+	assert.Equal(t, katapp.ConfigLayerOverlay, fileErr.Layer)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,115 @@
+package katapp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// findConfigFile returns path of configuration file with the name and one of supported extensions
+func findConfigFile(dir, name string) (string, error) {
+	for _, ext := range configFileExts {
+		file := filepath.Join(dir, name+"."+ext)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file, nil
+		}
+	}
+	return "", fmt.Errorf("%w (supported extensions: %s)", os.ErrNotExist, strings.Join(configFileExts, ", "))
+}
+
+// readConfigFile reads configuration file into a map with lowercase keys, format is detected by file extension
+func readConfigFile(file string) (map[string]any, error) {
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	return v.AllSettings(), nil
+}
+
+// mergeConfigMaps deeply merges src configuration map into dst. Values of src override values of dst,
+// except for lists of structs tagged with `mergekey` (e.g. `mergekey:"service"`), their items are merged
+// by value of the key: items with the same key are merged together and new items are appended.
+// Type t is a type of configuration struct (or its part) that dst is decoded into.
+func mergeConfigMaps(dst, src map[string]any, t reflect.Type) {
+	for k, sv := range src {
+		ft, mergeKey := configChildType(t, k)
+		switch s := sv.(type) {
+		case map[string]any:
+			if d, ok := dst[k].(map[string]any); ok {
+				mergeConfigMaps(d, s, ft)
+				continue
+			}
+		case []any:
+			if d, ok := dst[k].([]any); ok && mergeKey != "" {
+				dst[k] = mergeConfigListsByKey(d, s, mergeKey, elemType(ft))
+				continue
+			}
+		}
+		dst[k] = sv
+	}
+}
+
+func mergeConfigListsByKey(dst, src []any, mergeKey string, t reflect.Type) []any {
+	for _, sv := range src {
+		s, ok := sv.(map[string]any)
+		key, hasKey := s[mergeKey]
+		if !ok || !hasKey {
+			dst = append(dst, sv)
+			continue
+		}
+		merged := false
+		for _, dv := range dst {
+			if d, ok := dv.(map[string]any); ok && fmt.Sprint(d[mergeKey]) == fmt.Sprint(key) {
+				mergeConfigMaps(d, s, t)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			dst = append(dst, sv)
+		}
+	}
+	return dst
+}
+
+// configChildType returns type of the configuration key of struct (or map) type t and merge key
+// of the list (if key is a list tagged with `mergekey`). It returns nil type if key is unknown.
+func configChildType(t reflect.Type, key string) (reflect.Type, string) {
+	t = elemType(t)
+	if t == nil {
+		return nil, ""
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem(), ""
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			switch configFieldName(sf) {
+			case key:
+				return sf.Type, strings.ToLower(sf.Tag.Get("mergekey"))
+			case "":
+				if ft, mergeKey := configChildType(sf.Type, key); ft != nil {
+					return ft, mergeKey
+				}
+			}
+		}
+	}
+	return nil, ""
+}
+
+// elemType dereferences pointer types and returns element type of slices and arrays
+func elemType(t reflect.Type) reflect.Type {
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	return t
+}
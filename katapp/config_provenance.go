@@ -0,0 +1,213 @@
+package katapp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// ConfigLayer is a name of the configuration layer a value can come from
+type ConfigLayer string
+
+const (
+	// ConfigLayerCommon is a common configuration file (common.yaml)
+	ConfigLayerCommon ConfigLayer = "common"
+	// ConfigLayerDeployment is a deployment-specific configuration file (e.g. prod.yaml)
+	ConfigLayerDeployment ConfigLayer = "deployment"
+	// ConfigLayerOverlay is a deployment overlay configuration file (e.g. prod.eu-west.yaml)
+	ConfigLayerOverlay ConfigLayer = "overlay"
+	// ConfigLayerEnv is an environment variable overriding a configuration key
+	ConfigLayerEnv ConfigLayer = "env"
+	// ConfigLayerMerger is a value provided by merger function of LoadConfigWithMerger
+	ConfigLayerMerger ConfigLayer = "merger"
+)
+
+// ConfigValueSource describes a single place where a configuration key was defined
+type ConfigValueSource struct {
+	// Layer is a configuration layer the value came from
+	Layer ConfigLayer
+	// File is a configuration file the value was read from (for file layers only)
+	File string
+	// Line is a line number of the key in File (0 if unknown)
+	Line int
+	// EnvVar is a name of the environment variable the value was read from (for env layer only)
+	EnvVar string
+	// Value is a raw (not yet decoded) value defined by this source
+	Value any
+}
+
+func (s ConfigValueSource) String() string {
+	switch {
+	case s.EnvVar != "":
+		return "env " + s.EnvVar
+	case s.File != "" && s.Line > 0:
+		return fmt.Sprintf("%s:%d", s.File, s.Line)
+	case s.File != "":
+		return s.File
+	default:
+		return string(s.Layer)
+	}
+}
+
+// ConfigProvenance records where every resolved configuration key came from. Each key can be defined
+// by multiple layers, the last one (with the highest precedence) is the effective one.
+type ConfigProvenance struct {
+	envVarPrefix string
+	files        []string
+	sources      map[string][]ConfigValueSource
+}
+
+func newConfigProvenance(envVarPrefix string) *ConfigProvenance {
+	return &ConfigProvenance{
+		envVarPrefix: envVarPrefix,
+		sources:      make(map[string][]ConfigValueSource),
+	}
+}
+
+// Keys returns sorted list of all known configuration keys
+func (p *ConfigProvenance) Keys() []string {
+	keys := make([]string, 0, len(p.sources))
+	for k := range p.sources {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Files returns configuration files that were loaded, in order of increasing precedence
+func (p *ConfigProvenance) Files() []string {
+	return slices.Clone(p.files)
+}
+
+// Sources returns all sources defining the key in order of increasing precedence
+func (p *ConfigProvenance) Sources(key string) []ConfigValueSource {
+	return p.sources[strings.ToLower(key)]
+}
+
+// Source returns the effective source of the key
+func (p *ConfigProvenance) Source(key string) (ConfigValueSource, bool) {
+	sources := p.Sources(key)
+	if len(sources) == 0 {
+		return ConfigValueSource{}, false
+	}
+	return sources[len(sources)-1], true
+}
+
+// Explain renders a human-readable description of where value of the key came from and which
+// values it has overridden. If key is a prefix (e.g. "server"), all keys under it are explained.
+func (p *ConfigProvenance) Explain(key string) string {
+	key = strings.ToLower(key)
+	var keys []string
+	for _, k := range p.Keys() {
+		if k == key || strings.HasPrefix(k, key+".") {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return fmt.Sprintf("%s is not set in any configuration source", key)
+	}
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sources := p.sources[k]
+		effective := sources[len(sources)-1]
+		_, _ = fmt.Fprintf(&sb, "%s = %v (from %s, %s)", k, effective.Value, effective, effective.Layer)
+		for j := len(sources) - 2; j >= 0; j-- {
+			_, _ = fmt.Fprintf(&sb, "\n  overrides %v (from %s, %s)", sources[j].Value, sources[j], sources[j].Layer)
+		}
+	}
+	return sb.String()
+}
+
+func (p *ConfigProvenance) add(key string, src ConfigValueSource) {
+	key = strings.ToLower(key)
+	p.sources[key] = append(p.sources[key], src)
+}
+
+// addFile records all keys defined in configuration file. Line numbers are recorded for yaml and json
+// files, keys of other formats are recorded from already parsed settings of the file.
+func (p *ConfigProvenance) addFile(layer ConfigLayer, file string, settings map[string]any) error {
+	p.files = append(p.files, file)
+	ext := strings.ToLower(filepath.Ext(file))
+	switch ext {
+	case ".yaml", ".yml", ".json":
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var root yaml.Node
+		if err := yaml.Unmarshal(content, &root); err == nil {
+			if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+				p.addYamlNode(layer, file, "", root.Content[0])
+			}
+			return nil
+		} else if ext != ".json" {
+			return err
+		}
+	}
+	p.addSettings(layer, file, "", settings)
+	return nil
+}
+
+func (p *ConfigProvenance) addSettings(layer ConfigLayer, file string, prefix string, settings map[string]any) {
+	for k, v := range settings {
+		key := joinFieldPath(prefix, strings.ToLower(k))
+		if m, ok := v.(map[string]any); ok && len(m) > 0 {
+			p.addSettings(layer, file, key, m)
+			continue
+		}
+		p.add(key, ConfigValueSource{Layer: layer, File: file, Value: v})
+	}
+}
+
+func (p *ConfigProvenance) addYamlNode(layer ConfigLayer, file string, prefix string, node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := joinFieldPath(prefix, strings.ToLower(keyNode.Value))
+		if valueNode.Kind == yaml.MappingNode && len(valueNode.Content) > 0 {
+			p.addYamlNode(layer, file, key, valueNode)
+			continue
+		}
+		var value any
+		_ = valueNode.Decode(&value)
+		p.add(key, ConfigValueSource{Layer: layer, File: file, Line: keyNode.Line, Value: value})
+	}
+}
+
+// addEnv records environment variables overriding already known configuration keys
+func (p *ConfigProvenance) addEnv() {
+	for _, key := range p.Keys() {
+		name := configEnvVarName(p.envVarPrefix, key)
+		if value, ok := os.LookupEnv(name); ok {
+			p.add(key, ConfigValueSource{Layer: ConfigLayerEnv, EnvVar: name, Value: value})
+		}
+	}
+}
+
+// sourceOf returns a description of where value of the key (in a form of validation path,
+// e.g. "database.migrations[0].path") came from or empty string if key was not set
+func (p *ConfigProvenance) sourceOf(path string) string {
+	key, _, _ := strings.Cut(path, "[")
+	if src, ok := p.Source(key); ok {
+		return src.String()
+	}
+	return ""
+}
+
+// configEnvVarName returns a name of environment variable that overrides configuration key
+func configEnvVarName(envVarPrefix, key string) string {
+	name := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	if envVarPrefix != "" {
+		name = strings.ToUpper(envVarPrefix) + "_" + name
+	}
+	return name
+}
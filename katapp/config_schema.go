@@ -0,0 +1,238 @@
+package katapp
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// jsonSchemaDraft is a JSON Schema dialect of generated schemas (the one best supported by YAML language servers)
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// durationPattern matches duration strings accepted by time.ParseDuration, e.g. "1m30s"
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// JSONSchema is a (subset of) JSON Schema describing configuration struct
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	MinProperties        *int                   `json:"minProperties,omitempty"`
+	MaxProperties        *int                   `json:"maxProperties,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+}
+
+// ConfigSchema generates JSON Schema of configuration struct T, e.g. to be referenced from
+// configuration files for editor assistance:
+//
+//	# yaml-language-server: $schema=./config.schema.json
+//
+// Property names are taken from `mapstructure` tags (or field names starting with lowercase letter),
+// descriptions from `doc` tags and constraints from `validate` tags (see Validate). Values can be inherited
+// from common configuration, so `required` rules are not enforced by the schema of individual files
+// (see MergedConfigSchema).
+func ConfigSchema[T any]() *JSONSchema {
+	return newConfigSchema[T](false)
+}
+
+// MergedConfigSchema is similar to ConfigSchema, but it also requires values with `required` rules,
+// e.g. to check configuration merged from all files (see "config dump" subcommand)
+func MergedConfigSchema[T any]() *JSONSchema {
+	return newConfigSchema[T](true)
+}
+
+func newConfigSchema[T any](withRequired bool) *JSONSchema {
+	s := configSchemaBuilder{withRequired: withRequired}.typeSchema(reflect.TypeFor[T](), nil)
+	s.Schema = jsonSchemaDraft
+	return s
+}
+
+type configSchemaBuilder struct {
+	withRequired bool
+}
+
+func (b configSchemaBuilder) typeSchema(t reflect.Type, visiting []reflect.Type) *JSONSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case durationType:
+		return &JSONSchema{Type: "string", Pattern: durationPattern}
+	case timeType:
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: b.typeSchema(t.Elem(), visiting)}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: b.typeSchema(t.Elem(), visiting)}
+	case reflect.Struct:
+		s := &JSONSchema{Type: "object"}
+		for _, v := range visiting {
+			if v == t {
+				// recursive types are not expanded
+				return s
+			}
+		}
+		s.Properties = make(map[string]*JSONSchema)
+		b.addStruct(t, s, append(visiting, t))
+		return s
+	default:
+		return &JSONSchema{}
+	}
+}
+
+func (b configSchemaBuilder) addStruct(t reflect.Type, s *JSONSchema, visiting []reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := configSchemaPropertyName(sf)
+		switch name {
+		case "-":
+			continue
+		case "":
+			ft := sf.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.addStruct(ft, s, visiting)
+			}
+			continue
+		}
+		fs := b.typeSchema(sf.Type, visiting)
+		fs.Description = sf.Tag.Get("doc")
+		if applyValidationRulesToSchema(fs, sf.Type, sf.Tag.Get(validateTagName)) && b.withRequired {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fs
+	}
+}
+
+// configSchemaPropertyName returns name of configuration key as it is expected to be written in configuration
+// files. Keys are case-insensitive, so untagged fields are named in lower camel case, e.g. "maxConns".
+func configSchemaPropertyName(sf reflect.StructField) string {
+	name := configFieldName(sf)
+	if name == "" || name == "-" {
+		return name
+	}
+	if tagName, _, _ := strings.Cut(sf.Tag.Get("mapstructure"), ","); tagName != "" {
+		return tagName
+	}
+	r, size := utf8.DecodeRuneInString(sf.Name)
+	return string(unicode.ToLower(r)) + sf.Name[size:]
+}
+
+// applyValidationRulesToSchema adds constraints of `validate` tag rules to the schema
+// and returns true if value is required
+func applyValidationRulesToSchema(s *JSONSchema, t reflect.Type, tag string) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	required := false
+	rules := parseValidationRules(tag)
+	for _, rule := range rules {
+		switch rule.name {
+		case "required":
+			required = true
+		case "min", "max":
+			applyBoundToSchema(s, t, rule)
+		case "oneof":
+			for _, v := range strings.Fields(rule.param) {
+				s.Enum = append(s.Enum, schemaEnumValue(t, v))
+			}
+			if slices.ContainsFunc(rules, func(r validationRule) bool { return r.name == "omitempty" }) {
+				// empty value skips other rules
+				empty := ""
+				if t.Kind() != reflect.String {
+					empty = "0"
+				}
+				s.Enum = append(s.Enum, schemaEnumValue(t, empty))
+			}
+		case "url":
+			s.Format = "uri"
+		}
+	}
+	return required
+}
+
+func applyBoundToSchema(s *JSONSchema, t reflect.Type, rule validationRule) {
+	switch s.Type {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(rule.param, 64)
+		if err != nil {
+			return
+		}
+		if rule.name == "min" {
+			s.Minimum = &n
+		} else {
+			s.Maximum = &n
+		}
+	case "string", "array", "object":
+		if t == durationType {
+			// duration bounds cannot be expressed in JSON Schema
+			return
+		}
+		n, err := strconv.Atoi(rule.param)
+		if err != nil {
+			return
+		}
+		minBound, maxBound := &s.MinLength, &s.MaxLength
+		switch s.Type {
+		case "array":
+			minBound, maxBound = &s.MinItems, &s.MaxItems
+		case "object":
+			minBound, maxBound = &s.MinProperties, &s.MaxProperties
+		}
+		if rule.name == "min" {
+			*minBound = &n
+		} else {
+			*maxBound = &n
+		}
+	}
+}
+
+func schemaEnumValue(t reflect.Type, v string) any {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return v
+}
@@ -0,0 +1,72 @@
+package katapp_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaTestConfig struct {
+	Server   katapp.ServerConfig   `mapstructure:"server"`
+	Database katapp.DatabaseConfig `mapstructure:"database"`
+	Cache    katapp.CacheConfig    `mapstructure:"cache"`
+	Api      struct {
+		BaseUrl string        `mapstructure:"base_url" validate:"required,url" doc:"Base URL of the API"`
+		Retries *int          `validate:"min=0,max=5"`
+		Timeout time.Duration `validate:"min=1s"`
+		Tags    []string      `validate:"max=3"`
+	} `mapstructure:"api"`
+	Internal string `mapstructure:"-"`
+}
+
+func TestConfigSchema(t *testing.T) {
+	s := katapp.ConfigSchema[schemaTestConfig]()
+	assert.Equal(t, "http://json-schema.org/draft-07/schema#", s.Schema)
+	assert.Equal(t, "object", s.Type)
+	assert.NotContains(t, s.Properties, "internal")
+
+	server := s.Properties["server"]
+	require.NotNil(t, server)
+	assert.Empty(t, server.Required)
+	port := server.Properties["port"]
+	assert.Equal(t, "integer", port.Type)
+	assert.Equal(t, 1.0, *port.Minimum)
+	assert.Equal(t, 65535.0, *port.Maximum)
+	assert.Equal(t, "Network port to listen on", port.Description)
+	assert.Equal(t, []any{"gzip", ""}, server.Properties["responseCompression"].Enum)
+
+	db := s.Properties["database"]
+	assert.Equal(t, "array", db.Properties["migrations"].Type)
+	assert.Empty(t, db.Properties["migrations"].Items.Required)
+	assert.Equal(t, "string", db.Properties["parameters"].AdditionalProperties.Type)
+	assert.Equal(t, "integer", db.Properties["pool"].Properties["maxConns"].Type)
+	assert.Equal(t, "string", db.Properties["pool"].Properties["maxConnIdleTime"].Type)
+	assert.NotEmpty(t, db.Properties["pool"].Properties["maxConnIdleTime"].Pattern)
+	assert.Equal(t, []any{"none", "inmem", ""}, s.Properties["cache"].Properties["type"].Enum)
+
+	api := s.Properties["api"]
+	assert.Empty(t, api.Required)
+	assert.Equal(t, "uri", api.Properties["base_url"].Format)
+	assert.Equal(t, "Base URL of the API", api.Properties["base_url"].Description)
+	assert.Equal(t, "integer", api.Properties["retries"].Type)
+	assert.Equal(t, 5.0, *api.Properties["retries"].Maximum)
+	assert.Nil(t, api.Properties["timeout"].MinLength)
+	assert.Equal(t, 3, *api.Properties["tags"].MaxItems)
+
+	encoded, err := json.Marshal(s)
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"$schema":"http://json-schema.org/draft-07/schema#"`)
+	assert.Contains(t, string(encoded), `"port":{"description":"Network port to listen on","type":"integer","minimum":1,"maximum":65535}`)
+}
+
+func TestMergedConfigSchema_RequiresValues(t *testing.T) {
+	s := katapp.MergedConfigSchema[schemaTestConfig]()
+	assert.Equal(t, []string{"port"}, s.Properties["server"].Required)
+	assert.ElementsMatch(t, []string{"service", "path"},
+		s.Properties["database"].Properties["migrations"].Items.Required)
+	assert.Equal(t, []string{"base_url"}, s.Properties["api"].Required)
+}
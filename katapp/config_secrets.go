@@ -0,0 +1,195 @@
+package katapp
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ErrSecretNotFound must be returned (or wrapped) by SecretResolver if referenced secret does not exist.
+// Such references fall back to their default value (if provided), e.g. "${env:DB_PORT:-5432}".
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretResolver resolves a secret reference of a specific scheme. E.g. for "${file:/run/secrets/db_password}"
+// resolver registered for "file" scheme is called with "/run/secrets/db_password" reference.
+type SecretResolver func(ref string) (string, error)
+
+// SecretError is reported when a secret reference in configuration cannot be resolved
+type SecretError struct {
+	Scheme string
+	Ref    string
+	Err    error
+}
+
+func (e *SecretError) Error() string {
+	return fmt.Sprintf("failed to resolve secret reference ${%s:%s}: %v", e.Scheme, e.Ref, e.Err)
+}
+
+func (e *SecretError) Unwrap() error {
+	return e.Err
+}
+
+var secretResolvers = struct {
+	sync.RWMutex
+	m map[string]SecretResolver
+}{
+	m: map[string]SecretResolver{
+		"env":    resolveEnvSecret,
+		"file":   resolveFileSecret,
+		"base64": resolveBase64Secret,
+	},
+}
+
+// RegisterSecretResolver registers resolver for configuration values referencing secrets in a form of
+// "${scheme:ref}" or "${scheme:ref:-default}". Built-in schemes are "env", "file" and "base64".
+// It panics if resolver for the scheme was already registered.
+func RegisterSecretResolver(scheme string, resolver SecretResolver) {
+	secretResolvers.Lock()
+	defer secretResolvers.Unlock()
+	if _, ok := secretResolvers.m[scheme]; ok {
+		panic(fmt.Sprintf("secret resolver for scheme=%s was already registered", scheme))
+	}
+	secretResolvers.m[scheme] = resolver
+}
+
+// StaticSecretResolver returns resolver backed by a fixed set of secrets. It can be used as a local
+// stand-in for external secret stores (e.g. for "${vault:db#password}" in local or test deployments).
+func StaticSecretResolver(secrets map[string]string) SecretResolver {
+	return func(ref string) (string, error) {
+		if value, ok := secrets[ref]; ok {
+			return value, nil
+		}
+		return "", ErrSecretNotFound
+	}
+}
+
+func lookupSecretResolver(scheme string) SecretResolver {
+	secretResolvers.RLock()
+	defer secretResolvers.RUnlock()
+	return secretResolvers.m[scheme]
+}
+
+func resolveEnvSecret(ref string) (string, error) {
+	if value, ok := os.LookupEnv(ref); ok {
+		return value, nil
+	}
+	return "", ErrSecretNotFound
+}
+
+func resolveFileSecret(ref string) (string, error) {
+	content, err := os.ReadFile(ref)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+func resolveBase64Secret(ref string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(ref)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// secretResolution resolves references and decrypts encrypted values lazily (only for values being
+// decoded) and caches resolved secrets, so every reference is resolved only once per configuration load
+type secretResolution struct {
+	envVarPrefix string
+	keyring      *ConfigKeyring
+	cache        map[string]string
+}
+
+func newSecretResolution(envVarPrefix string) *secretResolution {
+	return &secretResolution{
+		envVarPrefix: envVarPrefix,
+		cache:        make(map[string]string),
+	}
+}
+
+// replaceReferencesHookFunc is a decode hook that resolves environment variables and secret references
+func (s *secretResolution) replaceReferencesHookFunc(
+	f reflect.Type,
+	_ reflect.Type,
+	data any,
+) (any, error) {
+	if f.Kind() != reflect.String {
+		return data, nil
+	}
+	value := data.(string)
+	if IsEncryptedValue(value) {
+		// Decrypted values are never expanded, secrets can contain '$' characters
+		return s.decrypt(value)
+	}
+	return s.expand(value)
+}
+
+func (s *secretResolution) decrypt(value string) (string, error) {
+	if s.keyring == nil {
+		keyring, err := LoadConfigKeyring(s.envVarPrefix)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt configuration value: %w", err)
+		}
+		s.keyring = keyring
+	}
+	plaintext, err := s.keyring.Decrypt(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt configuration value: %w", err)
+	}
+	return plaintext, nil
+}
+
+// expand replaces ${var}, $var and ${scheme:ref} references in the value. References without
+// registered scheme are treated as environment variables (optionally with default value, e.g.
+// "${PORT:-8080}") and are replaced with empty string if variable is not set.
+func (s *secretResolution) expand(value string) (string, error) {
+	var firstErr error
+	result := os.Expand(value, func(name string) string {
+		resolved, err := s.resolve(name)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return resolved
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return result, nil
+}
+
+func (s *secretResolution) resolve(name string) (string, error) {
+	if scheme, ref, ok := strings.Cut(name, ":"); ok {
+		if resolver := lookupSecretResolver(scheme); resolver != nil {
+			return s.resolveSecret(scheme, ref, resolver)
+		}
+	}
+	varName, defaultValue, hasDefault := strings.Cut(name, ":-")
+	if value := os.Getenv(varName); value != "" || !hasDefault {
+		return value, nil
+	}
+	return defaultValue, nil
+}
+
+func (s *secretResolution) resolveSecret(scheme, ref string, resolver SecretResolver) (string, error) {
+	ref, defaultValue, hasDefault := strings.Cut(ref, ":-")
+	cacheKey := scheme + ":" + ref
+	if value, ok := s.cache[cacheKey]; ok {
+		return value, nil
+	}
+	value, err := resolver(ref)
+	if errors.Is(err, ErrSecretNotFound) && hasDefault {
+		return defaultValue, nil
+	}
+	if err != nil {
+		return "", &SecretError{Scheme: scheme, Ref: ref, Err: err}
+	}
+	s.cache[cacheKey] = value
+	return value, nil
+}
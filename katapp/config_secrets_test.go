@@ -0,0 +1,104 @@
+package katapp_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var countingResolverCalls int
+
+func init() {
+	katapp.RegisterSecretResolver("testvault", katapp.StaticSecretResolver(map[string]string{
+		"db#user": "vault-user",
+	}))
+	katapp.RegisterSecretResolver("testcounting", func(ref string) (string, error) {
+		countingResolverCalls++
+		return ref, nil
+	})
+}
+
+func loadSecretsTestConfig(t *testing.T, content string) (*katapp.DatabaseConfig, error) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "local.yaml"), []byte(content), 0644))
+	type Config struct {
+		Database katapp.DatabaseConfig `mapstructure:"database"`
+	}
+	cfg, _, err := katapp.LoadConfigE[Config]("", katapp.Deployment{
+		Name:            "local",
+		ConfigDir:       tmpDir,
+		CommonConfigDir: tmpDir,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg.Database, nil
+}
+
+func TestLoad_ResolvesSecretReferences(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "db_password")
+	require.NoError(t, os.WriteFile(secretFile, []byte("file-secret\n"), 0600))
+	t.Setenv("SECRETS_TEST_DB_HOST", "db.internal")
+
+	db, err := loadSecretsTestConfig(t, `
+database:
+  host: "${env:SECRETS_TEST_DB_HOST}"
+  name: "${env:SECRETS_TEST_DB_NAME:-app}"
+  password: "${file:`+secretFile+`}"
+  sslmode: "${base64:ZGlzYWJsZQ==}"
+  user: "${testvault:db#user}"
+  port: "${SECRETS_TEST_DB_PORT:-5432}"
+  parameters:
+    application_name: "svc-${env:SECRETS_TEST_DB_HOST}"
+`)
+	require.NoError(t, err)
+	assert.Equal(t, "db.internal", db.Host)
+	assert.Equal(t, "app", db.Name)
+	assert.Equal(t, "file-secret", db.Password)
+	assert.Equal(t, "disable", db.Sslmode)
+	assert.Equal(t, "vault-user", db.User)
+	assert.Equal(t, 5432, db.Port)
+	assert.Equal(t, "svc-db.internal", db.Parameters["application_name"])
+}
+
+func TestLoad_ResolvesEachSecretOnce(t *testing.T) {
+	countingResolverCalls = 0
+	db, err := loadSecretsTestConfig(t, `
+database:
+  user: "${testcounting:shared}"
+  name: "${testcounting:shared}"
+`)
+	require.NoError(t, err)
+	assert.Equal(t, "shared", db.User)
+	assert.Equal(t, "shared", db.Name)
+	assert.Equal(t, 1, countingResolverCalls)
+}
+
+func TestLoad_ReportsMissingSecrets(t *testing.T) {
+	_, err := loadSecretsTestConfig(t, `
+database:
+  password: "${file:/nonexistent/db_password}"
+`)
+	var secretErr *katapp.SecretError
+	require.ErrorAs(t, err, &secretErr)
+	assert.Equal(t, "file", secretErr.Scheme)
+	assert.Equal(t, "/nonexistent/db_password", secretErr.Ref)
+	assert.ErrorIs(t, err, katapp.ErrSecretNotFound)
+
+	_, err = loadSecretsTestConfig(t, `
+database:
+  user: "${testvault:db#missing}"
+`)
+	assert.ErrorAs(t, err, &secretErr)
+}
+
+func TestRegisterSecretResolver_PanicsOnDuplicateScheme(t *testing.T) {
+	assert.Panics(t, func() {
+		katapp.RegisterSecretResolver("file", katapp.StaticSecretResolver(nil))
+	})
+}
@@ -0,0 +1,50 @@
+package katapp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfigViolation describes a configuration key that failed validation
+type ConfigViolation struct {
+	FieldViolation
+	// Source describes where the invalid value came from, e.g. "configs/prod.yaml:12" or "env APP_SERVER_PORT".
+	// It is empty if the key was not set anywhere.
+	Source string
+}
+
+// ConfigValidationError is reported when loaded configuration has one or more invalid keys.
+// It lists all invalid keys at once, so a broken configuration can be fixed in a single pass.
+type ConfigValidationError struct {
+	Violations []ConfigViolation
+}
+
+func (e *ConfigValidationError) Error() string {
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "invalid configuration (%d error(s)):", len(e.Violations))
+	for _, v := range e.Violations {
+		_, _ = fmt.Fprintf(&sb, "\n  - %s: %s", v.Field, v.Message)
+		if v.Source != "" {
+			_, _ = fmt.Fprintf(&sb, " [%s]", v.Source)
+		} else {
+			sb.WriteString(" [not set]")
+		}
+	}
+	return sb.String()
+}
+
+// validateConfig validates decoded configuration and reports all violations with their sources
+func validateConfig(cfg any, prov *ConfigProvenance) error {
+	vs := Validate(cfg)
+	if len(vs) == 0 {
+		return nil
+	}
+	err := &ConfigValidationError{}
+	for _, v := range vs {
+		err.Violations = append(err.Violations, ConfigViolation{
+			FieldViolation: v,
+			Source:         prov.sourceOf(v.Field),
+		})
+	}
+	return err
+}
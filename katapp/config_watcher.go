@@ -0,0 +1,284 @@
+package katapp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// kubernetesDataLink is a symlink to the data directory of mounted ConfigMap or Secret. Kubernetes swaps it
+// atomically on updates, configuration files are symlinks through it, so they produce no events themselves.
+const kubernetesDataLink = "..data"
+
+// configReloadDebounce is a delay to wait for more file events before reloading configuration
+// (editors often produce several write/rename events for a single save)
+const configReloadDebounce = 200 * time.Millisecond
+
+// ConfigWatcher keeps configuration up to date with common and deployment configuration files.
+// Every change of the files is decoded and validated the same way as LoadConfigE does, and only
+// then atomically swapped in. Invalid changes are rejected and the last good configuration stays in place.
+type ConfigWatcher[T any] struct {
+	envVarPrefix string
+	deployment   Deployment
+	merger       func() map[string]any
+
+	current atomic.Pointer[T]
+	prov    atomic.Pointer[ConfigProvenance]
+
+	reloadMu sync.Mutex // serializes reloads together with notifications of subscribers
+	mu       sync.Mutex // guards subscriptions
+	subs     []configSubscription
+	onErrors []func(err error)
+}
+
+type configSubscription struct {
+	key string
+	fn  func(old, new any)
+}
+
+// NewConfigWatcher loads configuration (see LoadConfigE) and returns a watcher for it.
+// Call Start to begin watching configuration files for changes.
+func NewConfigWatcher[T any](
+	envVarPrefix string,
+	deployment Deployment,
+	merger func() map[string]any,
+) (*ConfigWatcher[T], error) {
+	cfg, prov, err := LoadConfigE[T](envVarPrefix, deployment, merger)
+	if err != nil {
+		return nil, err
+	}
+	w := &ConfigWatcher[T]{
+		envVarPrefix: envVarPrefix,
+		deployment:   deployment,
+		merger:       merger,
+	}
+	w.current.Store(cfg)
+	w.prov.Store(prov)
+	return w, nil
+}
+
+// Config returns the current configuration. Returned value must be treated as read-only,
+// a new instance is created on every successful reload.
+func (w *ConfigWatcher[T]) Config() *T {
+	return w.current.Load()
+}
+
+// Provenance returns provenance of the current configuration
+func (w *ConfigWatcher[T]) Provenance() *ConfigProvenance {
+	return w.prov.Load()
+}
+
+// Subscribe registers a callback to be invoked with old and new values every time value of the
+// configuration key (e.g. "server.responsecompression") changes. It panics if key does not exist in T.
+func (w *ConfigWatcher[T]) Subscribe(key string, fn func(old, new any)) {
+	if _, err := configValueAt(reflect.ValueOf(w.Config()), key); err != nil {
+		panic(err.Error())
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subs = append(w.subs, configSubscription{key: key, fn: fn})
+}
+
+// OnError registers a callback to be invoked when reloaded configuration was rejected
+func (w *ConfigWatcher[T]) OnError(fn func(err error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onErrors = append(w.onErrors, fn)
+}
+
+// OnConfigChange is a typed version of ConfigWatcher.Subscribe. It panics if key does not exist in T
+// or if its type is not V, e.g.
+//
+//	katapp.OnConfigChange(w, "server.responsecompression", func(old, new string) { ... })
+func OnConfigChange[V any, T any](w *ConfigWatcher[T], key string, fn func(old, new V)) {
+	v, err := configValueAt(reflect.ValueOf(w.Config()), key)
+	if err != nil {
+		panic(err.Error())
+	}
+	if want := reflect.TypeFor[V](); v.Type() != want {
+		panic(fmt.Sprintf("configuration key %q is of type %s, not %s", key, v.Type(), want))
+	}
+	w.Subscribe(key, func(old, new any) {
+		fn(old.(V), new.(V))
+	})
+}
+
+// Start watches configuration files in the background until context is cancelled
+func (w *ConfigWatcher[T]) Start(ctx context.Context) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create configuration watcher: %w", err)
+	}
+	var dirs []string
+	for _, l := range w.deployment.configLayers() {
+		if !slices.Contains(dirs, l.dir) {
+			dirs = append(dirs, l.dir)
+		}
+	}
+	for _, dir := range dirs {
+		if err := fsw.Add(dir); err != nil {
+			_ = fsw.Close()
+			return fmt.Errorf("failed to watch configuration directory %s: %w", dir, err)
+		}
+	}
+	go w.run(ctx, fsw)
+	return nil
+}
+
+func (w *ConfigWatcher[T]) run(ctx context.Context, fsw *fsnotify.Watcher) {
+	logger := Logger(ctx).WithGroup("katapp.ConfigWatcher")
+	defer func() {
+		_ = fsw.Close()
+	}()
+
+	debounce := time.NewTimer(configReloadDebounce)
+	debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			debounce.Stop()
+			return
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return
+			}
+			if event.Op != fsnotify.Chmod && w.isConfigFile(event.Name) {
+				debounce.Reset(configReloadDebounce)
+			}
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return
+			}
+			logger.WarnContext(ctx, "configuration watcher error", "error", err)
+		case <-debounce.C:
+			_ = w.Reload(ctx)
+		}
+	}
+}
+
+// isConfigFile checks if file is one of the configuration files loaded by the watcher
+// (or a data symlink of Kubernetes that configuration files are resolved through)
+func (w *ConfigWatcher[T]) isConfigFile(file string) bool {
+	if filepath.Base(file) == kubernetesDataLink {
+		return true
+	}
+	if abs, err := filepath.Abs(file); err == nil && slices.Contains(w.Provenance().Files(), abs) {
+		return true
+	}
+	base := filepath.Base(file)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	return slices.ContainsFunc(w.deployment.configLayers(), func(l configFileLayer) bool {
+		return l.name == name
+	})
+}
+
+// Reload loads configuration again and swaps it in if it is valid. Subscribers of keys with
+// changed values are notified. If configuration is invalid, it is rejected and error is returned.
+// Concurrent reloads are serialized, so subscribers are notified in the order configurations were swapped in.
+// Callbacks can subscribe to other keys, but they must not call Reload.
+func (w *ConfigWatcher[T]) Reload(ctx context.Context) error {
+	logger := Logger(ctx).WithGroup("katapp.ConfigWatcher")
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+	cfg, prov, err := LoadConfigE[T](w.envVarPrefix, w.deployment, w.merger)
+	var old *T
+	if err == nil {
+		old = w.current.Swap(cfg)
+		w.prov.Store(prov)
+	}
+
+	w.mu.Lock()
+	subs := w.subs
+	onErrors := w.onErrors
+	w.mu.Unlock()
+
+	if err != nil {
+		logger.ErrorContext(ctx, "configuration change was rejected", "error", err)
+		for _, fn := range onErrors {
+			fn(err)
+		}
+		return err
+	}
+	logger.InfoContext(ctx, "configuration was reloaded")
+
+	oldValue, newValue := reflect.ValueOf(old), reflect.ValueOf(cfg)
+	for _, sub := range subs {
+		o, _ := configValueAt(oldValue, sub.key)
+		n, _ := configValueAt(newValue, sub.key)
+		if !reflect.DeepEqual(o.Interface(), n.Interface()) {
+			logger.InfoContext(ctx, "configuration key was changed", "key", sub.key)
+			sub.fn(o.Interface(), n.Interface())
+		}
+	}
+	return nil
+}
+
+// configValueAt returns value of the configuration key (e.g. "server.port") in the configuration struct.
+// Missing map entries and values behind nil pointers are returned as zero values.
+func configValueAt(v reflect.Value, key string) (reflect.Value, error) {
+	for _, part := range strings.Split(strings.ToLower(key), ".") {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v = reflect.Zero(v.Type().Elem())
+			} else {
+				v = v.Elem()
+			}
+		}
+		switch v.Kind() {
+		case reflect.Struct:
+			fv, ok := configFieldByName(v, part)
+			if !ok {
+				return reflect.Value{}, fmt.Errorf("unknown configuration key %q", key)
+			}
+			v = fv
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}, fmt.Errorf("unsupported configuration key %q", key)
+			}
+			mv := v.MapIndex(reflect.ValueOf(part).Convert(v.Type().Key()))
+			if !mv.IsValid() {
+				mv = reflect.Zero(v.Type().Elem())
+			}
+			v = mv
+		default:
+			return reflect.Value{}, fmt.Errorf("unknown configuration key %q", key)
+		}
+	}
+	return v, nil
+}
+
+func configFieldByName(v reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		switch configFieldName(sf) {
+		case name:
+			return v.Field(i), true
+		case "":
+			fv := v.Field(i)
+			for fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					fv = reflect.Zero(fv.Type().Elem())
+				} else {
+					fv = fv.Elem()
+				}
+			}
+			if fv.Kind() == reflect.Struct {
+				if found, ok := configFieldByName(fv, name); ok {
+					return found, true
+				}
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
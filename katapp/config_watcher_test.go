@@ -0,0 +1,213 @@
+package katapp_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kattest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type watcherTestConfig struct {
+	Server katapp.ServerConfig `mapstructure:"server"`
+	Cache  struct {
+		Ttl time.Duration
+	} `mapstructure:"cache"`
+}
+
+func setupConfigWatcher(t *testing.T, content string) (*katapp.ConfigWatcher[watcherTestConfig], string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	deploymentFile := filepath.Join(tmpDir, "local.yaml")
+	require.NoError(t, os.WriteFile(deploymentFile, []byte(content), 0644))
+	w, err := katapp.NewConfigWatcher[watcherTestConfig]("", katapp.Deployment{
+		Name:            "local",
+		ConfigDir:       tmpDir,
+		CommonConfigDir: tmpDir,
+	}, nil)
+	require.NoError(t, err)
+	return w, deploymentFile
+}
+
+func TestConfigWatcher_ReloadNotifiesSubscribers(t *testing.T) {
+	ctx := kattest.AppTestContext()
+	w, deploymentFile := setupConfigWatcher(t, "server:\n  port: 8080\ncache:\n  ttl: 1m\n")
+
+	var compressionChanges, ttlChanges, portChanges []string
+	katapp.OnConfigChange(w, "server.responsecompression", func(old, new string) {
+		compressionChanges = append(compressionChanges, old+"->"+new)
+	})
+	katapp.OnConfigChange(w, "cache.ttl", func(old, new time.Duration) {
+		ttlChanges = append(ttlChanges, old.String()+"->"+new.String())
+	})
+	w.Subscribe("server.port", func(old, new any) {
+		portChanges = append(portChanges, "changed")
+	})
+
+	require.NoError(t, os.WriteFile(deploymentFile,
+		[]byte("server:\n  port: 8080\n  responseCompression: gzip\ncache:\n  ttl: 5m\n"), 0644))
+	require.NoError(t, w.Reload(ctx))
+
+	assert.Equal(t, "gzip", w.Config().Server.ResponseCompression)
+	assert.Equal(t, []string{"->gzip"}, compressionChanges)
+	assert.Equal(t, []string{"1m0s->5m0s"}, ttlChanges)
+	assert.Empty(t, portChanges)
+}
+
+func TestConfigWatcher_RejectsInvalidChange(t *testing.T) {
+	ctx := kattest.AppTestContext()
+	w, deploymentFile := setupConfigWatcher(t, "server:\n  port: 8080\n")
+	previous := w.Config()
+
+	var reportedErr error
+	w.OnError(func(err error) {
+		reportedErr = err
+	})
+	notified := false
+	w.Subscribe("server.port", func(old, new any) {
+		notified = true
+	})
+
+	require.NoError(t, os.WriteFile(deploymentFile, []byte("server:\n  port: 70000\n"), 0644))
+	err := w.Reload(ctx)
+
+	var validationErr *katapp.ConfigValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, err, reportedErr)
+	assert.Same(t, previous, w.Config())
+	assert.Equal(t, 8080, w.Config().Server.Port)
+	assert.False(t, notified)
+}
+
+func TestConfigWatcher_CallbacksCanSubscribe(t *testing.T) {
+	ctx := kattest.AppTestContext()
+	w, deploymentFile := setupConfigWatcher(t, "server:\n  port: 8080\n")
+
+	w.OnError(func(err error) {
+		w.OnError(func(err error) {})
+	})
+	var ttlChanged bool
+	katapp.OnConfigChange(w, "server.port", func(old, new int) {
+		katapp.OnConfigChange(w, "cache.ttl", func(old, new time.Duration) {
+			ttlChanged = true
+		})
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, os.WriteFile(deploymentFile, []byte("server:\n  port: 70000\n"), 0644))
+		assert.Error(t, w.Reload(ctx))
+		assert.NoError(t, os.WriteFile(deploymentFile, []byte("server:\n  port: 9090\n"), 0644))
+		assert.NoError(t, w.Reload(ctx))
+		assert.NoError(t, os.WriteFile(deploymentFile, []byte("server:\n  port: 9090\ncache:\n  ttl: 1m\n"), 0644))
+		assert.NoError(t, w.Reload(ctx))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reload has deadlocked")
+	}
+	assert.True(t, ttlChanged)
+}
+
+func TestConfigWatcher_SubscribePanicsOnUnknownKeyOrType(t *testing.T) {
+	w, _ := setupConfigWatcher(t, "server:\n  port: 8080\n")
+	assert.Panics(t, func() {
+		w.Subscribe("server.unknown", func(old, new any) {})
+	})
+	assert.Panics(t, func() {
+		katapp.OnConfigChange(w, "server.port", func(old, new string) {})
+	})
+}
+
+func TestConfigWatcher_WatchesFileChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(kattest.AppTestContext())
+	defer cancel()
+	w, deploymentFile := setupConfigWatcher(t, "server:\n  port: 8080\n")
+
+	var port atomic.Int64
+	katapp.OnConfigChange(w, "server.port", func(old, new int) {
+		port.Store(int64(new))
+	})
+	require.NoError(t, w.Start(ctx))
+
+	require.NoError(t, os.WriteFile(deploymentFile, []byte("server:\n  port: 9090\n"), 0644))
+	assert.Eventually(t, func() bool {
+		return port.Load() == 9090
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, 9090, w.Config().Server.Port)
+}
+
+func TestConfigWatcher_NotifiesInOrderOfConcurrentReloads(t *testing.T) {
+	ctx := kattest.AppTestContext()
+	w, deploymentFile := setupConfigWatcher(t, "server:\n  port: 8080\n")
+
+	var changes [][2]int
+	katapp.OnConfigChange(w, "server.port", func(old, new int) {
+		changes = append(changes, [2]int{old, new})
+	})
+
+	var wg sync.WaitGroup
+	var port atomic.Int64
+	port.Store(8080)
+	for range 4 {
+		wg.Go(func() {
+			for range 10 {
+				content := fmt.Sprintf("server:\n  port: %d\n", port.Add(1))
+				assert.NoError(t, os.WriteFile(deploymentFile, []byte(content), 0644))
+				_ = w.Reload(ctx)
+			}
+		})
+	}
+	wg.Wait()
+
+	// every notification continues from the value reported by the previous one
+	prev := 8080
+	for _, change := range changes {
+		require.Equal(t, prev, change[0])
+		prev = change[1]
+	}
+	assert.Equal(t, w.Config().Server.Port, prev)
+}
+
+func TestConfigWatcher_WatchesKubernetesDataSymlinkSwap(t *testing.T) {
+	ctx, cancel := context.WithCancel(kattest.AppTestContext())
+	defer cancel()
+
+	// layout of mounted ConfigMap: local.yaml -> ..data/local.yaml, ..data -> ..v1
+	tmpDir := t.TempDir()
+	writeVersion := func(version string, content string) {
+		require.NoError(t, os.Mkdir(filepath.Join(tmpDir, version), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, version, "local.yaml"), []byte(content), 0644))
+	}
+	writeVersion("..v1", "server:\n  port: 8080\n")
+	require.NoError(t, os.Symlink("..v1", filepath.Join(tmpDir, "..data")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "local.yaml"), filepath.Join(tmpDir, "local.yaml")))
+
+	w, err := katapp.NewConfigWatcher[watcherTestConfig]("", katapp.Deployment{
+		Name:            "local",
+		ConfigDir:       tmpDir,
+		CommonConfigDir: tmpDir,
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 8080, w.Config().Server.Port)
+	require.NoError(t, w.Start(ctx))
+
+	// Kubernetes writes new version and atomically replaces the data symlink
+	writeVersion("..v2", "server:\n  port: 9090\n")
+	require.NoError(t, os.Symlink("..v2", filepath.Join(tmpDir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(tmpDir, "..data_tmp"), filepath.Join(tmpDir, "..data")))
+
+	assert.Eventually(t, func() bool {
+		return w.Config().Server.Port == 9090
+	}, 5*time.Second, 50*time.Millisecond)
+}
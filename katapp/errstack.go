@@ -0,0 +1,141 @@
+package katapp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// errStackDepth is a maximum number of frames captured for application errors
+const errStackDepth = 32
+
+// errFingerprintFrames is a number of top frames used to compute error fingerprint
+const errFingerprintFrames = 3
+
+var errStackCapture atomic.Bool
+
+func init() {
+	errStackCapture.Store(true)
+}
+
+// SetErrStackCapture enables or disables capturing of call stack for internal and unknown application
+// errors when they are created (enabled by default). Stack can still be captured explicitly with WithStack.
+func SetErrStackCapture(enabled bool) {
+	errStackCapture.Store(enabled)
+}
+
+// newErr creates application error and captures call stack of the caller for internal and unknown errors
+func newErr(scope ErrScope, msg string, cause error) *Err {
+	e := &Err{Scope: scope, Msg: msg, Cause: cause}
+	if (scope == ErrInternal || scope == ErrUnknown) && errStackCapture.Load() {
+		e.stack = callers(4)
+	}
+	return e
+}
+
+func callers(skip int) []uintptr {
+	var pcs [errStackDepth]uintptr
+	n := runtime.Callers(skip, pcs[:])
+	return pcs[:n]
+}
+
+// WithStack captures call stack of the caller (it replaces previously captured stack)
+func (e *Err) WithStack() *Err {
+	e.stack = callers(3)
+	return e
+}
+
+// StackTrace returns frames of the call stack captured when error was created (nil if stack was not captured)
+func (e *Err) StackTrace() []runtime.Frame {
+	if len(e.stack) == 0 {
+		return nil
+	}
+	var frames []runtime.Frame
+	iter := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, frame)
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+// Stack returns formatted call stack captured when error was created (empty if stack was not captured)
+func (e *Err) Stack() string {
+	var sb strings.Builder
+	for _, frame := range e.StackTrace() {
+		_, _ = fmt.Fprintf(&sb, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+	}
+	return sb.String()
+}
+
+// Fingerprint returns a stable identifier of the error origin. It is computed from error code and names
+// of functions the error was created in (but not from line numbers or message, so it survives unrelated
+// code changes and does not depend on variable data), errors with the same origin have the same fingerprint.
+func (e *Err) Fingerprint() string {
+	h := sha256.New()
+	h.Write([]byte(e.ErrCode()))
+	frames := e.StackTrace()
+	if len(frames) == 0 {
+		h.Write([]byte(e.Msg))
+	}
+	for i, frame := range frames {
+		if i == errFingerprintFrames {
+			break
+		}
+		h.Write([]byte{0})
+		h.Write([]byte(frame.Function))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// ErrFingerprint returns fingerprint of the first application error in the error chain or
+// a fingerprint computed from error type and message for other errors
+func ErrFingerprint(err error) string {
+	if appErr, ok := AsErr(err); ok {
+		return appErr.Fingerprint()
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%T\x00%s", err, err.Error())))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ErrStack returns formatted call stack of the first application error in the error chain that
+// has captured stack (empty if there is none)
+func ErrStack(err error) string {
+	for err != nil {
+		if appErr, ok := err.(*Err); ok && len(appErr.stack) > 0 {
+			return appErr.Stack()
+		}
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return ""
+		}
+		err = u.Unwrap()
+	}
+	return ""
+}
+
+type errStackLoggedContextKey struct{}
+
+// ContextWithErrStackLogOnce returns a new context that tracks if error stack was already logged
+// (see MarkErrStackLogged). Request contexts created by ContextWithRequestLogger already track it.
+func ContextWithErrStackLogOnce(ctx context.Context) context.Context {
+	return context.WithValue(ctx, errStackLoggedContextKey{}, new(atomic.Bool))
+}
+
+// MarkErrStackLogged returns true only for the first call within a context created by ContextWithErrStackLogOnce
+// (or ContextWithRequestLogger), so error stack is logged only once per request. It always returns true
+// for contexts that do not track it.
+func MarkErrStackLogged(ctx context.Context) bool {
+	logged, ok := ctx.Value(errStackLoggedContextKey{}).(*atomic.Bool)
+	if !ok {
+		return true
+	}
+	return logged.CompareAndSwap(false, true)
+}
@@ -0,0 +1,8 @@
+package katapp
+
+import "github.com/labstack/gommon/log"
+
+func init() {
+	// loading of invalid configuration must not exit test binaries
+	configFatalf = log.Panicf
+}
@@ -0,0 +1,194 @@
+package katapp
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sync/atomic"
+)
+
+// Flag bucketing keys (see FlagConfig.BucketBy)
+const (
+	FlagBucketByPrincipal = "principal"
+	FlagBucketByTenant    = "tenant"
+	FlagBucketByRequest   = "request"
+)
+
+// FlagConfig defines a feature flag. Flag is enabled for everyone if Enabled is set, otherwise it is
+// enabled for listed tenants and principals and for Rollout percent of bucketing keys. Variant flags
+// additionally assign a weighted variant to every bucketing key the flag is enabled for.
+type FlagConfig struct {
+	// Name is a unique name of the flag
+	Name string `json:"name" validate:"required" doc:"Unique name of the flag"`
+	// Enabled enables the flag for everyone
+	Enabled bool `json:"enabled,omitempty" doc:"Enable the flag for everyone"`
+	// Rollout is a percentage of bucketing keys the flag is enabled for
+	Rollout int `json:"rollout,omitempty" validate:"min=0,max=100" doc:"Percentage of bucketing keys the flag is enabled for"`
+	// Tenants are tenant IDs the flag is always enabled for
+	Tenants []string `json:"tenants,omitempty" doc:"Tenant IDs the flag is always enabled for"`
+	// Subjects are principal subjects the flag is always enabled for
+	Subjects []string `json:"subjects,omitempty" doc:"Principal subjects the flag is always enabled for"`
+	// BucketBy is a key used for percentage rollout and variant assignment (principal subject, tenant ID or
+	// request ID). By default the first available of principal subject, tenant ID and request ID is used.
+	BucketBy string `json:"bucketBy,omitempty" validate:"omitempty,oneof=principal tenant request" doc:"Key used for percentage rollout and variant assignment"`
+	// Variants are weighted variants of the flag
+	Variants []FlagVariantConfig `json:"variants,omitempty" mergekey:"name" doc:"Weighted variants of the flag"`
+}
+
+// FlagVariantConfig is a weighted variant of feature flag
+type FlagVariantConfig struct {
+	Name   string `json:"name" validate:"required" doc:"Name of the variant"`
+	Weight int    `json:"weight" validate:"min=0" doc:"Relative weight of the variant"`
+}
+
+// FlagVariantOn is a variant of enabled flags without variants
+const FlagVariantOn = "on"
+
+// Flags evaluates feature flags against request context (principal, tenant and request ID, see
+// ContextWithPrincipal and ContextWithTenant). Flags are defined in configuration and can be
+// overridden at runtime (e.g. by katpg.FlagStore). Unknown flags are disabled.
+type Flags struct {
+	defined   atomic.Pointer[map[string]FlagConfig]
+	overrides atomic.Pointer[map[string]FlagConfig]
+}
+
+// NewFlags creates feature flags from configuration
+func NewFlags(cfgs []FlagConfig) (*Flags, error) {
+	f := &Flags{}
+	f.overrides.Store(&map[string]FlagConfig{})
+	if err := f.Update(cfgs); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Update replaces flags defined in configuration (e.g. when configuration is reloaded),
+// runtime overrides are kept
+func (f *Flags) Update(cfgs []FlagConfig) error {
+	defined, err := flagsByName(cfgs)
+	if err != nil {
+		return err
+	}
+	f.defined.Store(&defined)
+	return nil
+}
+
+// SetOverrides replaces runtime overrides of flags, overridden flags are evaluated
+// with their override configuration instead of the defined one
+func (f *Flags) SetOverrides(cfgs []FlagConfig) error {
+	overrides, err := flagsByName(cfgs)
+	if err != nil {
+		return err
+	}
+	f.overrides.Store(&overrides)
+	return nil
+}
+
+func flagsByName(cfgs []FlagConfig) (map[string]FlagConfig, error) {
+	flags := make(map[string]FlagConfig, len(cfgs))
+	for _, cfg := range cfgs {
+		if vs := Validate(&cfg); len(vs) > 0 {
+			return nil, fmt.Errorf("invalid feature flag %q: %s %s", cfg.Name, vs[0].Field, vs[0].Message)
+		}
+		if _, ok := flags[cfg.Name]; ok {
+			return nil, fmt.Errorf("duplicate feature flag %q", cfg.Name)
+		}
+		flags[cfg.Name] = cfg
+	}
+	return flags, nil
+}
+
+// Flag returns effective configuration of the flag (overridden or defined one)
+func (f *Flags) Flag(name string) (FlagConfig, bool) {
+	if cfg, ok := (*f.overrides.Load())[name]; ok {
+		return cfg, true
+	}
+	cfg, ok := (*f.defined.Load())[name]
+	return cfg, ok
+}
+
+// Enabled checks if flag is enabled for the context
+func (f *Flags) Enabled(ctx context.Context, name string) bool {
+	cfg, ok := f.Flag(name)
+	return ok && flagEnabled(ctx, cfg)
+}
+
+// Variant returns variant of the flag assigned to the context, it is FlagVariantOn for enabled flags
+// without variants and empty string if flag is disabled for the context
+func (f *Flags) Variant(ctx context.Context, name string) string {
+	cfg, ok := f.Flag(name)
+	if !ok || !flagEnabled(ctx, cfg) {
+		return ""
+	}
+	total := 0
+	for _, v := range cfg.Variants {
+		total += v.Weight
+	}
+	if total == 0 {
+		return FlagVariantOn
+	}
+	bucket := flagBucket(cfg.Name+"#variant", flagBucketKey(ctx, cfg), total)
+	for _, v := range cfg.Variants {
+		if bucket < v.Weight {
+			return v.Name
+		}
+		bucket -= v.Weight
+	}
+	return FlagVariantOn
+}
+
+func flagEnabled(ctx context.Context, cfg FlagConfig) bool {
+	if cfg.Enabled {
+		return true
+	}
+	if tenantID, ok := TenantFrom(ctx); ok && slices.Contains(cfg.Tenants, tenantID) {
+		return true
+	}
+	if principal, ok := PrincipalFrom(ctx); ok && slices.Contains(cfg.Subjects, principal.Subject) {
+		return true
+	}
+	if cfg.Rollout <= 0 {
+		return false
+	}
+	key := flagBucketKey(ctx, cfg)
+	return key != "" && flagBucket(cfg.Name, key, 100) < cfg.Rollout
+}
+
+// flagBucketKey returns key the context is assigned to buckets by
+func flagBucketKey(ctx context.Context, cfg FlagConfig) string {
+	principal := func() string {
+		if p, ok := PrincipalFrom(ctx); ok {
+			return p.Subject
+		}
+		return ""
+	}
+	tenant := func() string {
+		tenantID, _ := TenantFrom(ctx)
+		return tenantID
+	}
+	switch cfg.BucketBy {
+	case FlagBucketByPrincipal:
+		return principal()
+	case FlagBucketByTenant:
+		return tenant()
+	case FlagBucketByRequest:
+		return RequestID(ctx)
+	default:
+		for _, key := range []string{principal(), tenant()} {
+			if key != "" {
+				return key
+			}
+		}
+		return RequestID(ctx)
+	}
+}
+
+// flagBucket assigns key to one of n buckets, assignment is stable for the same flag and key
+func flagBucket(flag string, key string, n int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(flag))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
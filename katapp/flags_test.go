@@ -0,0 +1,105 @@
+package katapp_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func principalContext(subject string) context.Context {
+	return katapp.ContextWithPrincipal(context.Background(), &katapp.Principal{Subject: subject})
+}
+
+func TestFlags_LoadedFromConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "local.yaml"), []byte(`
+flags:
+  - name: new-checkout
+    enabled: true
+  - name: beta-search
+    tenants: [acme]
+    subjects: [alice]
+`), 0644))
+	type Config struct {
+		Flags []katapp.FlagConfig `mapstructure:"flags" mergekey:"name"`
+	}
+	cfg, _, err := katapp.LoadConfigE[Config]("", katapp.Deployment{
+		Name:            "local",
+		ConfigDir:       tmpDir,
+		CommonConfigDir: tmpDir,
+	}, nil)
+	require.NoError(t, err)
+	flags, err := katapp.NewFlags(cfg.Flags)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assert.True(t, flags.Enabled(ctx, "new-checkout"))
+	assert.False(t, flags.Enabled(ctx, "beta-search"))
+	assert.True(t, flags.Enabled(katapp.ContextWithTenant(ctx, "acme"), "beta-search"))
+	assert.True(t, flags.Enabled(principalContext("alice"), "beta-search"))
+	assert.False(t, flags.Enabled(principalContext("bob"), "beta-search"))
+	assert.False(t, flags.Enabled(ctx, "unknown"))
+}
+
+func TestFlags_RolloutIsStickyAndProportional(t *testing.T) {
+	flags, err := katapp.NewFlags([]katapp.FlagConfig{{Name: "rollout", Rollout: 30}})
+	require.NoError(t, err)
+
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		ctx := principalContext(fmt.Sprintf("user-%d", i))
+		first := flags.Enabled(ctx, "rollout")
+		assert.Equal(t, first, flags.Enabled(ctx, "rollout"))
+		if first {
+			enabled++
+		}
+	}
+	assert.InDelta(t, 300, enabled, 60)
+
+	// requests without principal and tenant are bucketed by request ID
+	reqCtx := katapp.ContextWithRequestLogger(context.Background(), slog.New(slog.DiscardHandler), "req-1")
+	assert.Equal(t, flags.Enabled(reqCtx, "rollout"), flags.Enabled(reqCtx, "rollout"))
+	assert.False(t, flags.Enabled(context.Background(), "rollout"))
+}
+
+func TestFlags_AssignsVariants(t *testing.T) {
+	flags, err := katapp.NewFlags([]katapp.FlagConfig{
+		{Name: "layout", Enabled: true, Variants: []katapp.FlagVariantConfig{
+			{Name: "control", Weight: 50},
+			{Name: "compact", Weight: 50},
+		}},
+		{Name: "simple", Enabled: true},
+		{Name: "off"},
+	})
+	require.NoError(t, err)
+
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		counts[flags.Variant(principalContext(fmt.Sprintf("user-%d", i)), "layout")]++
+	}
+	assert.Len(t, counts, 2)
+	assert.InDelta(t, 500, counts["compact"], 80)
+	assert.Equal(t, katapp.FlagVariantOn, flags.Variant(context.Background(), "simple"))
+	assert.Empty(t, flags.Variant(context.Background(), "off"))
+}
+
+func TestFlags_OverridesTakePrecedence(t *testing.T) {
+	flags, err := katapp.NewFlags([]katapp.FlagConfig{{Name: "feature", Enabled: true}})
+	require.NoError(t, err)
+	require.NoError(t, flags.SetOverrides([]katapp.FlagConfig{{Name: "feature"}}))
+	assert.False(t, flags.Enabled(context.Background(), "feature"))
+	require.NoError(t, flags.SetOverrides(nil))
+	assert.True(t, flags.Enabled(context.Background(), "feature"))
+
+	_, err = katapp.NewFlags([]katapp.FlagConfig{{Name: "bad", Rollout: 150}})
+	assert.Error(t, err)
+	_, err = katapp.NewFlags([]katapp.FlagConfig{{Name: "dup"}, {Name: "dup"}})
+	assert.Error(t, err)
+}
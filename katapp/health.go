@@ -0,0 +1,198 @@
+package katapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultHealthCheckTimeout is a timeout of health checks that do not specify their own timeout
+const DefaultHealthCheckTimeout = 2 * time.Second
+
+// HealthStatus is a status of a health check or of the whole health report
+type HealthStatus string
+
+const (
+	// HealthStatusUp means that check has passed
+	HealthStatusUp HealthStatus = "up"
+	// HealthStatusDegraded means that some non-critical checks have failed
+	HealthStatusDegraded HealthStatus = "degraded"
+	// HealthStatusDown means that check (or at least one critical check of the report) has failed
+	HealthStatusDown HealthStatus = "down"
+)
+
+// HealthCheck is a named check of a component health, e.g. database ping
+type HealthCheck struct {
+	// Name is a unique name of the check, e.g. "postgres"
+	Name string
+	// Check returns error if component is not healthy
+	Check func(ctx context.Context) error
+	// Details optionally provides additional information to be reported with the check result
+	Details func() map[string]any
+	// Timeout is a maximum duration of the check (DefaultHealthCheckTimeout if zero)
+	Timeout time.Duration
+	// Critical checks make application not ready (or not alive) when they fail,
+	// failures of other checks are reported, but make application degraded only
+	Critical bool
+	// Liveness checks are also performed by liveness probe. They must fail only if the process is broken
+	// and has to be restarted (a failed database connection is not a reason to restart the process).
+	Liveness bool
+}
+
+// HealthCheckResult is a result of a single health check
+type HealthCheckResult struct {
+	Status    HealthStatus   `json:"status"`
+	Critical  bool           `json:"critical"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+	Duration  string         `json:"duration"`
+	CheckedAt time.Time      `json:"checkedAt"`
+}
+
+// HealthReport is a result of liveness or readiness probe
+type HealthReport struct {
+	Status HealthStatus                 `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks"`
+}
+
+// Healthy returns false if any critical check has failed
+func (r *HealthReport) Healthy() bool {
+	return r.Status != HealthStatusDown
+}
+
+// HealthRegistry runs registered health checks for liveness and readiness probes. Results of checks are
+// cached for a configured duration, so frequent probes do not overload checked services (e.g. database).
+type HealthRegistry struct {
+	cacheTTL time.Duration
+
+	mu     sync.RWMutex
+	checks []*registeredHealthCheck
+}
+
+type registeredHealthCheck struct {
+	HealthCheck
+	mu     sync.Mutex // serializes runs of the check, so concurrent probes share a single result
+	result HealthCheckResult
+}
+
+// NewHealthRegistry creates health registry that caches check results for cacheTTL (0 disables caching)
+func NewHealthRegistry(cacheTTL time.Duration) *HealthRegistry {
+	return &HealthRegistry{cacheTTL: cacheTTL}
+}
+
+// Register adds health check to the registry. It panics if check with the same name was already registered.
+func (r *HealthRegistry) Register(check HealthCheck) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.checks {
+		if c.Name == check.Name {
+			panic(fmt.Sprintf("health check %s was already registered", check.Name))
+		}
+	}
+	r.checks = append(r.checks, &registeredHealthCheck{HealthCheck: check})
+}
+
+// Liveness runs liveness checks (see HealthCheck.Liveness)
+func (r *HealthRegistry) Liveness(ctx context.Context) *HealthReport {
+	return r.report(ctx, true)
+}
+
+// Readiness runs all registered checks
+func (r *HealthRegistry) Readiness(ctx context.Context) *HealthReport {
+	return r.report(ctx, false)
+}
+
+func (r *HealthRegistry) report(ctx context.Context, livenessOnly bool) *HealthReport {
+	r.mu.RLock()
+	var checks []*registeredHealthCheck
+	for _, c := range r.checks {
+		if !livenessOnly || c.Liveness {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]HealthCheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Go(func() {
+			results[i] = c.run(ctx, r.cacheTTL)
+		})
+	}
+	wg.Wait()
+
+	report := &HealthReport{Status: HealthStatusUp, Checks: make(map[string]HealthCheckResult, len(checks))}
+	for i, c := range checks {
+		report.Checks[c.Name] = results[i]
+		if results[i].Status == HealthStatusUp {
+			continue
+		}
+		if c.Critical {
+			report.Status = HealthStatusDown
+		} else if report.Status == HealthStatusUp {
+			report.Status = HealthStatusDegraded
+		}
+	}
+	return report
+}
+
+// run returns cached result of the check or performs the check if cached result has expired
+func (c *registeredHealthCheck) run(ctx context.Context, cacheTTL time.Duration) HealthCheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.result.CheckedAt.IsZero() && time.Since(c.result.CheckedAt) < cacheTTL {
+		return c.result
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+	// result is shared with other probes, so it must not depend on cancellation of this probe
+	checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	started := time.Now()
+	err := runHealthCheck(checkCtx, c.Check)
+	result := HealthCheckResult{
+		Status:    HealthStatusUp,
+		Critical:  c.Critical,
+		Duration:  time.Since(started).String(),
+		CheckedAt: started,
+	}
+	if err != nil {
+		result.Status = HealthStatusDown
+		result.Error = err.Error()
+		Logger(ctx).WithGroup("katapp.HealthRegistry").WarnContext(ctx, "health check has failed",
+			"check", c.Name, "error", err)
+	}
+	if c.Details != nil {
+		result.Details = c.Details()
+	}
+	c.result = result
+	return result
+}
+
+// runHealthCheck runs check, but does not wait for it longer than context deadline
+func runHealthCheck(ctx context.Context, check func(ctx context.Context) error) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("health check panicked: %v", r)
+			}
+		}()
+		done <- check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return errors.New("health check timed out")
+		}
+		return ctx.Err()
+	}
+}
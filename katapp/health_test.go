@@ -0,0 +1,99 @@
+package katapp_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kattest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthRegistry_ReportsCheckStatuses(t *testing.T) {
+	ctx := kattest.AppTestContext()
+	registry := katapp.NewHealthRegistry(0)
+	registry.Register(katapp.HealthCheck{
+		Name:     "db",
+		Check:    func(ctx context.Context) error { return nil },
+		Critical: true,
+		Details:  func() map[string]any { return map[string]any{"conns": 3} },
+	})
+	registry.Register(katapp.HealthCheck{
+		Name:  "leader",
+		Check: func(ctx context.Context) error { return errors.New("not running") },
+	})
+	registry.Register(katapp.HealthCheck{
+		Name:     "runner",
+		Check:    func(ctx context.Context) error { return nil },
+		Critical: true,
+		Liveness: true,
+	})
+
+	report := registry.Readiness(ctx)
+	assert.Equal(t, katapp.HealthStatusDegraded, report.Status)
+	assert.True(t, report.Healthy())
+	assert.Len(t, report.Checks, 3)
+	assert.Equal(t, katapp.HealthStatusUp, report.Checks["db"].Status)
+	assert.Equal(t, map[string]any{"conns": 3}, report.Checks["db"].Details)
+	assert.Equal(t, katapp.HealthStatusDown, report.Checks["leader"].Status)
+	assert.Equal(t, "not running", report.Checks["leader"].Error)
+
+	liveness := registry.Liveness(ctx)
+	assert.Equal(t, katapp.HealthStatusUp, liveness.Status)
+	assert.Len(t, liveness.Checks, 1)
+	assert.Contains(t, liveness.Checks, "runner")
+
+	registry.Register(katapp.HealthCheck{
+		Name:     "redis",
+		Check:    func(ctx context.Context) error { panic("boom") },
+		Critical: true,
+	})
+	report = registry.Readiness(ctx)
+	assert.Equal(t, katapp.HealthStatusDown, report.Status)
+	assert.False(t, report.Healthy())
+	assert.Contains(t, report.Checks["redis"].Error, "boom")
+
+	assert.Panics(t, func() {
+		registry.Register(katapp.HealthCheck{Name: "db"})
+	})
+}
+
+func TestHealthRegistry_CachesResults(t *testing.T) {
+	ctx := kattest.AppTestContext()
+	registry := katapp.NewHealthRegistry(time.Minute)
+	var calls atomic.Int32
+	registry.Register(katapp.HealthCheck{
+		Name: "db",
+		Check: func(ctx context.Context) error {
+			calls.Add(1)
+			return nil
+		},
+		Critical: true,
+	})
+	for range 5 {
+		registry.Readiness(ctx)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestHealthRegistry_TimesOutSlowChecks(t *testing.T) {
+	registry := katapp.NewHealthRegistry(0)
+	registry.Register(katapp.HealthCheck{
+		Name: "slow",
+		Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(time.Second)
+			return nil
+		},
+		Timeout:  20 * time.Millisecond,
+		Critical: true,
+	})
+	started := time.Now()
+	report := registry.Readiness(kattest.AppTestContext())
+	assert.Less(t, time.Since(started), 500*time.Millisecond)
+	assert.Equal(t, katapp.HealthStatusDown, report.Status)
+	assert.Equal(t, "health check timed out", report.Checks["slow"].Error)
+}
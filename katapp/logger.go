@@ -30,6 +30,7 @@ func ContextWithAddedGroup(ctx context.Context, group string) context.Context {
 func ContextWithRequestLogger(ctx context.Context, logger *slog.Logger, reqID string) context.Context {
 	logger = logger.With(RequestIdKey, reqID)
 	ctx = context.WithValue(ctx, RequestIdKey, reqID)
+	ctx = ContextWithErrStackLogOnce(ctx)
 	return context.WithValue(ctx, loggerContextKey{}, logger)
 }
 
@@ -65,6 +66,35 @@ func (l *KatLogger) With(args ...any) *KatLogger {
 	}
 }
 
+// Redacted returns logger that masks sensitive values with the default redactor (see RedactString),
+// regardless of redaction configured for the application logger
+func (l *KatLogger) Redacted() *KatLogger {
+	if hasRedactingHandler(l.Handler()) {
+		return l
+	}
+	return &KatLogger{
+		Logger: slog.New(NewRedactingHandler(l.Handler(), DefaultRedactor())),
+		ctx:    l.ctx,
+	}
+}
+
+// hasRedactingHandler checks if records of the handler are already redacted, it walks the chain of
+// handlers created by NewLogHandler
+func hasRedactingHandler(h slog.Handler) bool {
+	for {
+		switch hh := h.(type) {
+		case *RedactingHandler:
+			return true
+		case *levelControlHandler:
+			h = hh.inner
+		case *SamplingHandler:
+			h = hh.inner
+		default:
+			return false
+		}
+	}
+}
+
 func (l *KatLogger) Debugf(format string, a ...any) {
 	l.log(slog.LevelDebug, fmt.Sprintf(format, a...))
 }
@@ -0,0 +1,22 @@
+package katapp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKatLogger_RedactedDoesNotWrapRedactingLogger(t *testing.T) {
+	for _, cfg := range []LoggingConfig{
+		{},
+		{Sampling: LogSamplingConfig{First: 10}},
+	} {
+		logger, closer, err := NewLogger(cfg)
+		require.NoError(t, err)
+		katLogger := &KatLogger{Logger: logger.WithGroup("app"), ctx: context.Background()}
+		assert.Same(t, katLogger, katLogger.Redacted())
+		require.NoError(t, closer.Close())
+	}
+}
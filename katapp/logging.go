@@ -0,0 +1,177 @@
+package katapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"sync"
+)
+
+// NewLogger creates application logger from logging configuration. Returned closer releases
+// log file (if any) and must be closed when logger is not used anymore.
+func NewLogger(cfg LoggingConfig) (*slog.Logger, io.Closer, error) {
+	handler, closer, err := NewLogHandler(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return slog.New(handler), closer, nil
+}
+
+// NewLogHandler creates slog handler from logging configuration (see NewLogger)
+func NewLogHandler(cfg LoggingConfig) (slog.Handler, io.Closer, error) {
+	levels, err := newLevelControllerFromConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	redactor, err := newRedactorFromConfig(cfg.Redaction)
+	if err != nil {
+		return nil, nil, err
+	}
+	w, err := openLogOutput(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts := &slog.HandlerOptions{
+		AddSource: cfg.AddSource,
+		// levels are checked by levelControlHandler
+		Level: slog.Level(math.MinInt),
+	}
+	var inner slog.Handler
+	switch cfg.Format {
+	case "json":
+		inner = slog.NewJSONHandler(w, opts)
+	case "", "text":
+		inner = slog.NewTextHandler(w, opts)
+	default:
+		_ = w.Close()
+		return nil, nil, fmt.Errorf("unsupported log format %q", cfg.Format)
+	}
+	inner = NewRedactingHandler(inner, redactor)
+	if cfg.Sampling.First > 0 || cfg.Sampling.RateLimit > 0 {
+		sampling := NewSamplingHandler(inner, cfg.Sampling, SystemClock)
+		closer := &flushingCloser{sampling: sampling, stopSweeper: sampling.StartSweeper(), output: w}
+		return &levelControlHandler{inner: sampling, levels: levels}, closer, nil
+	}
+	return &levelControlHandler{inner: inner, levels: levels}, w, nil
+}
+
+// openLogOutput opens destination of log records
+func openLogOutput(cfg LoggingConfig) (io.WriteCloser, error) {
+	switch cfg.Output {
+	case "", "stderr":
+		return nopWriteCloser{os.Stderr}, nil
+	case "stdout":
+		return nopWriteCloser{os.Stdout}, nil
+	case "file":
+		if cfg.File.Path == "" {
+			return nil, errors.New("log file path is not set")
+		}
+		return openRotatingFile(cfg.File.Path, int64(cfg.File.MaxSize)*1024*1024, cfg.File.MaxBackups)
+	default:
+		return nil, fmt.Errorf("unsupported log output %q", cfg.Output)
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// flushingCloser stops sweeping of sampling handler and logs its pending summaries before closing log output
+type flushingCloser struct {
+	sampling    *SamplingHandler
+	stopSweeper func()
+	output      io.Closer
+}
+
+func (c *flushingCloser) Close() error {
+	c.stopSweeper()
+	c.sampling.Flush(context.Background())
+	return c.output.Close()
+}
+
+// rotatingFile is a log file that is rotated when it reaches maximum size. Rotated files are
+// renamed with numeric suffixes (e.g. "app.log.1" is the most recent one).
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %s: %w", f.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to open log file %s: %w", f.path, err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate shifts rotated files, removes the oldest one and starts a new log file
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file %s: %w", f.path, err)
+	}
+	backup := func(i int) string {
+		return fmt.Sprintf("%s.%d", f.path, i)
+	}
+	if err := os.Remove(backup(f.maxBackups)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove rotated log file: %w", err)
+	}
+	for i := f.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(backup(i), backup(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	}
+	if f.maxBackups > 0 {
+		if err := os.Rename(f.path, backup(1)); err != nil {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	} else if err := os.Remove(f.path); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return f.open()
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
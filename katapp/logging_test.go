@@ -0,0 +1,76 @@
+package katapp_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger_AppliesGroupLevels(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	logger, closer, err := katapp.NewLogger(katapp.LoggingConfig{
+		Format: "json",
+		Level:  "warn",
+		Groups: []katapp.LogGroupConfig{
+			{Group: "katpg", Level: "debug"},
+			{Group: "katpg.DoMigration", Level: "error"},
+			{Group: "kvtcache", Level: "info"},
+		},
+		Output: "file",
+		File:   katapp.LogFileConfig{Path: logFile},
+	})
+	require.NoError(t, err)
+
+	logger.Info("global info")
+	logger.Warn("global warn")
+	logger.WithGroup("katpg.LeaderElector").Debug("leader debug")
+	logger.WithGroup("katpg.DoMigration").Warn("migration warn")
+	logger.WithGroup("katpg.DoMigration").WithGroup("step").Error("migration error")
+	logger.WithGroup("KVTCache").Info("cache info")
+	logger.WithGroup("katpgx").Info("other info")
+	require.NoError(t, closer.Close())
+
+	content, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	for _, msg := range []string{"global warn", "leader debug", "migration error", "cache info"} {
+		assert.Contains(t, string(content), `"msg":"`+msg+`"`)
+	}
+	for _, msg := range []string{"global info", "migration warn", "other info"} {
+		assert.NotContains(t, string(content), `"msg":"`+msg+`"`)
+	}
+}
+
+func TestNewLogger_RotatesLogFile(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	logger, closer, err := katapp.NewLogger(katapp.LoggingConfig{
+		Output: "file",
+		File:   katapp.LogFileConfig{Path: logFile, MaxSize: 1, MaxBackups: 2},
+	})
+	require.NoError(t, err)
+	line := strings.Repeat("x", 64*1024)
+	for i := 0; i < 60; i++ {
+		logger.Info(line)
+	}
+	require.NoError(t, closer.Close())
+
+	for _, file := range []string{logFile, logFile + ".1", logFile + ".2"} {
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(1024*1024))
+	}
+	assert.NoFileExists(t, logFile+".3")
+}
+
+func TestNewLogger_RejectsInvalidConfig(t *testing.T) {
+	_, _, err := katapp.NewLogger(katapp.LoggingConfig{Output: "file"})
+	assert.Error(t, err)
+	_, _, err = katapp.NewLogger(katapp.LoggingConfig{Level: "verbose"})
+	assert.Error(t, err)
+	_, _, err = katapp.NewLogger(katapp.LoggingConfig{Format: "xml"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,193 @@
+package katapp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LevelController changes minimum levels of log records globally and per log group at runtime.
+// Loggers created by NewLogger are controlled by their controller (see LevelControllerOf).
+type LevelController struct {
+	global slog.LevelVar
+	mu     sync.Mutex // serializes changes of group levels
+	groups atomic.Pointer[[]logGroupLevel]
+}
+
+type logGroupLevel struct {
+	group string
+	level *slog.LevelVar
+}
+
+// NewLevelController creates controller with global minimum level
+func NewLevelController(level slog.Level) *LevelController {
+	c := &LevelController{}
+	c.global.Set(level)
+	c.groups.Store(&[]logGroupLevel{})
+	return c
+}
+
+func newLevelControllerFromConfig(cfg LoggingConfig) (*LevelController, error) {
+	level, err := ParseLogLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	c := NewLevelController(level)
+	for _, g := range cfg.Groups {
+		level, err := ParseLogLevel(g.Level)
+		if err != nil {
+			return nil, fmt.Errorf("log group %s: %w", g.Group, err)
+		}
+		c.SetGroupLevel(g.Group, level)
+	}
+	return c, nil
+}
+
+// ParseLogLevel parses level names, e.g. "debug" or "warn" ("info" if empty)
+func ParseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", s)
+	}
+	return level, nil
+}
+
+// Level returns global minimum level
+func (c *LevelController) Level() slog.Level {
+	return c.global.Level()
+}
+
+// SetLevel changes global minimum level
+func (c *LevelController) SetLevel(level slog.Level) {
+	c.global.Set(level)
+}
+
+// GroupLevels returns minimum levels overridden for log groups
+func (c *LevelController) GroupLevels() map[string]slog.Level {
+	levels := make(map[string]slog.Level)
+	for _, g := range *c.groups.Load() {
+		levels[g.group] = g.level.Level()
+	}
+	return levels
+}
+
+// SetGroupLevel overrides minimum level for a log group (and groups nested in it, see LogGroupConfig)
+func (c *LevelController) SetGroupLevel(group string, level slog.Level) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	groups := *c.groups.Load()
+	for _, g := range groups {
+		if strings.EqualFold(g.group, group) {
+			g.level.Set(level)
+			return
+		}
+	}
+	g := logGroupLevel{group: group, level: &slog.LevelVar{}}
+	g.level.Set(level)
+	updated := append(groups[:len(groups):len(groups)], g)
+	c.groups.Store(&updated)
+}
+
+// ResetGroupLevel removes minimum level override of a log group, it returns false if there was no override
+func (c *LevelController) ResetGroupLevel(group string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	groups := *c.groups.Load()
+	for i, g := range groups {
+		if strings.EqualFold(g.group, group) {
+			updated := append(groups[:i:i], groups[i+1:]...)
+			c.groups.Store(&updated)
+			return true
+		}
+	}
+	return false
+}
+
+// levelFor returns minimum level for a chain of log groups. Level of the innermost group with
+// overridden level is used, groups are matched by the longest overridden name.
+func (c *LevelController) levelFor(groups []string) slog.Level {
+	if len(groups) > 0 {
+		overrides := *c.groups.Load()
+		for i := len(groups) - 1; i >= 0; i-- {
+			var matched *logGroupLevel
+			for j, g := range overrides {
+				if logGroupMatches(groups[i], g.group) && (matched == nil || len(g.group) > len(matched.group)) {
+					matched = &overrides[j]
+				}
+			}
+			if matched != nil {
+				return matched.level.Level()
+			}
+		}
+	}
+	return c.global.Level()
+}
+
+// logGroupMatches checks if group name equals to configured name or is nested in it (e.g. "katpg"
+// matches "katpg.DoMigration"), names are compared case-insensitively
+func logGroupMatches(name string, configured string) bool {
+	if len(name) < len(configured) || !strings.EqualFold(name[:len(configured)], configured) {
+		return false
+	}
+	return len(name) == len(configured) || name[len(configured)] == '.'
+}
+
+// LevelControllerOf returns level controller of the logger created by NewLogger (nil for other loggers)
+func LevelControllerOf(logger *slog.Logger) *LevelController {
+	if h, ok := logger.Handler().(*levelControlHandler); ok {
+		return h.levels
+	}
+	return nil
+}
+
+// ContextWithDebugLogging returns a new context with request logger that logs records of all levels
+// (starting from Debug) regardless of configured levels, e.g. to debug a single request. It has
+// no effect on loggers that were not created by NewLogger.
+func ContextWithDebugLogging(ctx context.Context) context.Context {
+	logger := Logger(ctx).Logger
+	h, ok := logger.Handler().(*levelControlHandler)
+	if !ok || h.debug {
+		return ctx
+	}
+	debug := *h
+	debug.debug = true
+	return context.WithValue(ctx, loggerContextKey{}, slog.New(&debug))
+}
+
+// levelControlHandler filters log records by minimum level of log groups the handler was created for
+type levelControlHandler struct {
+	inner  slog.Handler
+	levels *LevelController
+	groups []string
+	debug  bool // log records of all levels starting from Debug
+}
+
+func (h *levelControlHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if h.debug && level >= slog.LevelDebug {
+		return true
+	}
+	return level >= h.levels.levelFor(h.groups)
+}
+
+func (h *levelControlHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *levelControlHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.inner = h.inner.WithAttrs(attrs)
+	return &c
+}
+
+func (h *levelControlHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.inner = h.inner.WithGroup(name)
+	c.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &c
+}
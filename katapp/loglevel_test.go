@@ -0,0 +1,71 @@
+package katapp_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLevelTestLogger(t *testing.T) (*slog.Logger, func() string) {
+	t.Helper()
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	logger, closer, err := katapp.NewLogger(katapp.LoggingConfig{
+		Format: "json",
+		Output: "file",
+		File:   katapp.LogFileConfig{Path: logFile},
+	})
+	require.NoError(t, err)
+	return logger, func() string {
+		require.NoError(t, closer.Close())
+		content, err := os.ReadFile(logFile)
+		require.NoError(t, err)
+		return string(content)
+	}
+}
+
+func TestLevelController_ChangesLevelsAtRuntime(t *testing.T) {
+	logger, output := newLevelTestLogger(t)
+	levels := katapp.LevelControllerOf(logger)
+	require.NotNil(t, levels)
+	cacheLogger := logger.WithGroup("KVTCache")
+
+	cacheLogger.Debug("cache debug before")
+	levels.SetGroupLevel("kvtcache", slog.LevelDebug)
+	cacheLogger.Debug("cache debug after")
+	logger.Debug("global debug")
+	levels.SetLevel(slog.LevelError)
+	logger.Warn("global warn")
+	assert.True(t, levels.ResetGroupLevel("KVTCache"))
+	cacheLogger.Warn("cache warn after reset")
+	assert.Equal(t, map[string]slog.Level{}, levels.GroupLevels())
+
+	content := output()
+	assert.Contains(t, content, `"msg":"cache debug after"`)
+	for _, msg := range []string{"cache debug before", "global debug", "global warn", "cache warn after reset"} {
+		assert.NotContains(t, content, `"msg":"`+msg+`"`)
+	}
+}
+
+func TestContextWithDebugLogging_RaisesVerbosityOfRequest(t *testing.T) {
+	logger, output := newLevelTestLogger(t)
+	ctx := katapp.ContextWithRequestLogger(context.Background(), logger, "req-1")
+	debugCtx := katapp.ContextWithDebugLogging(ctx)
+
+	katapp.Logger(ctx).Debug("regular request debug")
+	katapp.Logger(debugCtx).WithGroup("katpg.DoMigration").Debug("debug request debug")
+
+	content := output()
+	assert.Contains(t, content, `"msg":"debug request debug"`)
+	assert.Contains(t, content, `"requestId":"req-1"`)
+	assert.NotContains(t, content, `"msg":"regular request debug"`)
+}
+
+func TestLevelControllerOf_ReturnsNilForOtherLoggers(t *testing.T) {
+	assert.Nil(t, katapp.LevelControllerOf(slog.Default()))
+}
@@ -0,0 +1,247 @@
+package katapp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+// defaultLogSamplingInterval is a period sampling counters are reset after if it is not configured
+const defaultLogSamplingInterval = time.Second
+
+// maxLogSamplingKeys is a number of tracked messages after which counters of expired intervals are swept
+const maxLogSamplingKeys = 4096
+
+// SamplingHandler limits number of repeated log records. Records with the same level and message
+// (in the same log group) are sampled per interval: First records are logged, then every Thereafter-th one.
+// When the interval of sampled message ends, a summary record reports how many times it was repeated
+// (when the message recurs or when counters are swept, see StartSweeper).
+// Additionally, all records are limited by a global token bucket (see LogSamplingConfig).
+type SamplingHandler struct {
+	inner  slog.Handler
+	groups string
+	state  *logSamplingState
+}
+
+type logSamplingState struct {
+	mu       sync.Mutex
+	cfg      LogSamplingConfig
+	clock    Clock
+	root     slog.Handler
+	counters map[string]*logSamplingCounter
+
+	tokens      float64
+	lastRefill  time.Time
+	rateDropped int
+}
+
+type logSamplingCounter struct {
+	start   time.Time
+	count   int
+	dropped int
+	last    slog.Record  // last dropped record
+	inner   slog.Handler // handler of the last dropped record
+}
+
+// NewSamplingHandler wraps handler with sampling, deduplication and rate limiting of log records
+func NewSamplingHandler(inner slog.Handler, cfg LogSamplingConfig, clock Clock) *SamplingHandler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultLogSamplingInterval
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = int(math.Max(1, math.Ceil(cfg.RateLimit)))
+	}
+	return &SamplingHandler{
+		inner: inner,
+		state: &logSamplingState{
+			cfg:      cfg,
+			clock:    clock,
+			root:     inner,
+			counters: make(map[string]*logSamplingCounter),
+			tokens:   float64(cfg.Burst),
+		},
+	}
+}
+
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	summaries, ok := h.state.admit(h, r)
+	for _, s := range summaries {
+		_ = s.inner.Handle(ctx, s.record)
+	}
+	if !ok {
+		return nil
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.inner = h.inner.WithAttrs(attrs)
+	return &c
+}
+
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.inner = h.inner.WithGroup(name)
+	c.groups = h.groups + name + "."
+	return &c
+}
+
+// Sweep logs summaries of messages whose sampling intervals have ended, so summaries of bursts are
+// reported even if messages do not recur (see StartSweeper)
+func (h *SamplingHandler) Sweep(ctx context.Context) {
+	st := h.state
+	st.mu.Lock()
+	summaries := st.sweep(st.clock.Now())
+	if s, ok := st.rateSummary(); ok {
+		summaries = append(summaries, s)
+	}
+	st.mu.Unlock()
+	for _, s := range summaries {
+		_ = s.inner.Handle(ctx, s.record)
+	}
+}
+
+// StartSweeper sweeps counters (see Sweep) in background every sampling interval until returned
+// stop function is called
+func (h *SamplingHandler) StartSweeper() (stop func()) {
+	ticker := time.NewTicker(h.state.cfg.Interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				h.Sweep(context.Background())
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+// Flush logs summaries of all messages that were dropped by sampling or rate limiting so far,
+// e.g. before application exits
+func (h *SamplingHandler) Flush(ctx context.Context) {
+	st := h.state
+	st.mu.Lock()
+	var summaries []logSummary
+	for key, c := range st.counters {
+		if c.dropped > 0 {
+			summaries = append(summaries, c.summary())
+		}
+		delete(st.counters, key)
+	}
+	if s, ok := st.rateSummary(); ok {
+		summaries = append(summaries, s)
+	}
+	st.mu.Unlock()
+	for _, s := range summaries {
+		_ = s.inner.Handle(ctx, s.record)
+	}
+}
+
+type logSummary struct {
+	inner  slog.Handler
+	record slog.Record
+}
+
+// admit decides if record must be logged and returns summaries of dropped records to log before it
+func (st *logSamplingState) admit(h *SamplingHandler, r slog.Record) ([]logSummary, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	now := st.clock.Now()
+	var summaries []logSummary
+
+	if st.cfg.First > 0 {
+		key := h.groups + r.Level.String() + "\x00" + r.Message
+		c, ok := st.counters[key]
+		if !ok {
+			if len(st.counters) >= maxLogSamplingKeys {
+				summaries = append(summaries, st.sweep(now)...)
+			}
+			c = &logSamplingCounter{start: now}
+			st.counters[key] = c
+		} else if now.Sub(c.start) >= st.cfg.Interval {
+			if c.dropped > 0 {
+				summaries = append(summaries, c.summary())
+			}
+			*c = logSamplingCounter{start: now}
+		}
+		c.count++
+		if c.count > st.cfg.First && (st.cfg.Thereafter == 0 || (c.count-st.cfg.First)%st.cfg.Thereafter != 0) {
+			c.dropped++
+			c.last = r.Clone()
+			c.inner = h.inner
+			return summaries, false
+		}
+	}
+
+	if st.cfg.RateLimit > 0 {
+		if !st.lastRefill.IsZero() {
+			st.tokens += now.Sub(st.lastRefill).Seconds() * st.cfg.RateLimit
+			st.tokens = math.Min(st.tokens, float64(st.cfg.Burst))
+		}
+		st.lastRefill = now
+		if st.tokens < 1 {
+			st.rateDropped++
+			return summaries, false
+		}
+		st.tokens--
+		if s, ok := st.rateSummary(); ok {
+			summaries = append(summaries, s)
+		}
+	}
+	return summaries, true
+}
+
+// sweep removes counters of expired intervals and returns summaries of their dropped records
+func (st *logSamplingState) sweep(now time.Time) []logSummary {
+	var summaries []logSummary
+	for key, c := range st.counters {
+		if now.Sub(c.start) >= st.cfg.Interval {
+			if c.dropped > 0 {
+				summaries = append(summaries, c.summary())
+			}
+			delete(st.counters, key)
+		}
+	}
+	return summaries
+}
+
+// rateSummary returns summary of records dropped by rate limiting (if any) and resets the counter
+func (st *logSamplingState) rateSummary() (logSummary, bool) {
+	if st.rateDropped == 0 {
+		return logSummary{}, false
+	}
+	r := slog.NewRecord(st.clock.Now(), slog.LevelWarn,
+		fmt.Sprintf("%d log records were dropped by rate limit", st.rateDropped), 0)
+	r.AddAttrs(slog.Int("dropped", st.rateDropped))
+	st.rateDropped = 0
+	return logSummary{inner: st.root, record: r}, true
+}
+
+// summary returns record reporting how many times the last dropped record was repeated
+func (c *logSamplingCounter) summary() logSummary {
+	msg := fmt.Sprintf("%s (repeated %d times)", c.last.Message, c.dropped)
+	r := slog.NewRecord(c.last.Time, c.last.Level, msg, c.last.PC)
+	c.last.Attrs(func(a slog.Attr) bool {
+		r.AddAttrs(a)
+		return true
+	})
+	r.AddAttrs(slog.Int("repeated", c.dropped))
+	return logSummary{inner: c.inner, record: r}
+}
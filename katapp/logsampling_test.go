@@ -0,0 +1,144 @@
+package katapp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kattest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSamplingTestLogger(
+	cfg katapp.LogSamplingConfig,
+) (*slog.Logger, *katapp.SamplingHandler, *kattest.FakeClock, func() []map[string]any) {
+	var buf bytes.Buffer
+	clock := kattest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	h := katapp.NewSamplingHandler(slog.NewJSONHandler(&buf, nil), cfg, clock)
+	return slog.New(h), h, clock, func() []map[string]any {
+		var records []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err == nil {
+				records = append(records, record)
+			}
+		}
+		buf.Reset()
+		return records
+	}
+}
+
+func messages(records []map[string]any) []string {
+	var msgs []string
+	for _, r := range records {
+		msgs = append(msgs, r["msg"].(string))
+	}
+	return msgs
+}
+
+func TestSamplingHandler_SamplesAndSummarizesRepeatedMessages(t *testing.T) {
+	logger, _, clock, records := newSamplingTestLogger(katapp.LogSamplingConfig{
+		Interval:   time.Second,
+		First:      2,
+		Thereafter: 3,
+	})
+	for i := 0; i < 10; i++ {
+		logger.Error("cache refresh failed", "attempt", i)
+	}
+	logger.Info("other message")
+	// records 1, 2, 5 and 8 are logged
+	assert.Equal(t, []string{"cache refresh failed", "cache refresh failed", "cache refresh failed",
+		"cache refresh failed", "other message"}, messages(records()))
+
+	clock.Advance(time.Second)
+	logger.Error("cache refresh failed", "attempt", 10)
+	logged := records()
+	require.Len(t, logged, 2)
+	assert.Equal(t, "cache refresh failed (repeated 6 times)", logged[0]["msg"])
+	assert.EqualValues(t, 6, logged[0]["repeated"])
+	assert.EqualValues(t, 9, logged[0]["attempt"])
+	assert.Equal(t, "cache refresh failed", logged[1]["msg"])
+}
+
+func TestSamplingHandler_TracksGroupsSeparately(t *testing.T) {
+	logger, h, _, records := newSamplingTestLogger(katapp.LogSamplingConfig{First: 1})
+	for i := 0; i < 3; i++ {
+		logger.WithGroup("KVTCache").Warn("failed")
+		logger.WithGroup("RedisCache").Warn("failed")
+	}
+	assert.Len(t, records(), 2)
+
+	h.Flush(context.Background())
+	logged := records()
+	require.Len(t, logged, 2)
+	for _, r := range logged {
+		assert.Equal(t, "failed (repeated 2 times)", r["msg"])
+	}
+}
+
+func TestSamplingHandler_LimitsRate(t *testing.T) {
+	logger, _, clock, records := newSamplingTestLogger(katapp.LogSamplingConfig{RateLimit: 2, Burst: 3})
+	for i := 0; i < 5; i++ {
+		logger.Info("request", "n", i)
+	}
+	assert.Len(t, records(), 3)
+
+	clock.Advance(500 * time.Millisecond)
+	logger.Info("request", "n", 5)
+	logger.Info("request", "n", 6)
+	logged := records()
+	require.Len(t, logged, 2)
+	assert.Equal(t, "2 log records were dropped by rate limit", logged[0]["msg"])
+	assert.EqualValues(t, 2, logged[0]["dropped"])
+	assert.EqualValues(t, 5, logged[1]["n"])
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSamplingHandler_SweeperReportsEndedBursts(t *testing.T) {
+	var buf syncBuffer
+	clock := kattest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	h := katapp.NewSamplingHandler(slog.NewJSONHandler(&buf, nil), katapp.LogSamplingConfig{
+		Interval: 20 * time.Millisecond,
+		First:    1,
+	}, clock)
+	stop := h.StartSweeper()
+	defer stop()
+
+	logger := slog.New(h)
+	for i := 0; i < 5; i++ {
+		logger.Error("cache refresh failed")
+	}
+	assert.NotContains(t, buf.String(), "repeated")
+
+	// burst stops, summary is reported once its interval ends
+	clock.Advance(time.Second)
+	assert.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), `"msg":"cache refresh failed (repeated 4 times)"`)
+	}, time.Second, 10*time.Millisecond)
+}
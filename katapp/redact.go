@@ -0,0 +1,217 @@
+package katapp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// RedactedValue replaces redacted values in logs
+const RedactedValue = "***"
+
+// Secret is a string that is never revealed in logs or formatted output, it always appears as "***".
+// Use Reveal (or string conversion) to access the actual value.
+type Secret string
+
+// Reveal returns the actual value of the secret
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	return RedactedValue
+}
+
+func (s Secret) GoString() string {
+	return RedactedValue
+}
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(RedactedValue)
+}
+
+// DefaultRedactedKeys are attribute keys with values that are always masked. Keys are matched
+// case-insensitively as substrings, e.g. "password" also matches "db_password".
+var DefaultRedactedKeys = []string{
+	"password", "passwd", "secret", "token", "authorization", "cookie", "apikey", "api_key", "email",
+}
+
+var (
+	bearerTokenPattern = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9\-._~+/]+=*`)
+	cardNumberPattern  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	urlPasswordPattern = regexp.MustCompile(`(://[^:/@\s]+:)[^@\s/]+@`)
+)
+
+// Redactor masks sensitive values: values of attributes with sensitive keys, secrets in strings
+// (bearer tokens, card numbers, passwords in URLs and sensitive URL query parameters) and custom patterns
+type Redactor struct {
+	keys        []string
+	queryParams *regexp.Regexp
+	patterns    []*regexp.Regexp
+}
+
+// NewRedactor creates redactor for additional attribute keys (DefaultRedactedKeys are always masked)
+// and additional patterns (text matched by patterns is replaced with "***")
+func NewRedactor(keys []string, patterns ...*regexp.Regexp) *Redactor {
+	r := &Redactor{patterns: patterns}
+	var quoted []string
+	for _, key := range append(DefaultRedactedKeys[:len(DefaultRedactedKeys):len(DefaultRedactedKeys)], keys...) {
+		r.keys = append(r.keys, strings.ToLower(key))
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+	r.queryParams = regexp.MustCompile(`(?i)([?&][^=&\s]*(?:` + strings.Join(quoted, "|") + `)[^=&\s]*=)[^&\s#]+`)
+	return r
+}
+
+func newRedactorFromConfig(cfg LogRedactionConfig) (*Redactor, error) {
+	var patterns []*regexp.Regexp
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log redaction pattern %q: %w", p, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return NewRedactor(cfg.Keys, patterns...), nil
+}
+
+var defaultRedactor atomic.Pointer[Redactor]
+
+func init() {
+	defaultRedactor.Store(NewRedactor(nil))
+}
+
+// DefaultRedactor returns redactor used by RedactString (it masks DefaultRedactedKeys and built-in patterns)
+func DefaultRedactor() *Redactor {
+	return defaultRedactor.Load()
+}
+
+// SetDefaultRedactor replaces redactor used by RedactString
+func SetDefaultRedactor(r *Redactor) {
+	defaultRedactor.Store(r)
+}
+
+// RedactString masks secrets in the string with the default redactor
+func RedactString(s string) string {
+	return DefaultRedactor().RedactString(s)
+}
+
+// IsRedactedKey checks if values of attributes with the key must be masked
+func (r *Redactor) IsRedactedKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactString masks secrets in the string
+func (r *Redactor) RedactString(s string) string {
+	s = bearerTokenPattern.ReplaceAllString(s, "$1 "+RedactedValue)
+	s = urlPasswordPattern.ReplaceAllString(s, "${1}"+RedactedValue+"@")
+	s = r.queryParams.ReplaceAllString(s, "${1}"+RedactedValue)
+	s = cardNumberPattern.ReplaceAllStringFunc(s, func(number string) string {
+		if luhnValid(number) {
+			return RedactedValue
+		}
+		return number
+	})
+	for _, p := range r.patterns {
+		s = p.ReplaceAllString(s, RedactedValue)
+	}
+	return s
+}
+
+// RedactAttr masks value of attribute with sensitive key or secrets in its value
+func (r *Redactor) RedactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if r.IsRedactedKey(a.Key) {
+		return slog.String(a.Key, RedactedValue)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(r.RedactString(a.Value.String()))
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		redacted := make([]slog.Attr, len(attrs))
+		for i, ga := range attrs {
+			redacted[i] = r.RedactAttr(ga)
+		}
+		a.Value = slog.GroupValue(redacted...)
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			if s := v.Error(); r.RedactString(s) != s {
+				a.Value = slog.StringValue(r.RedactString(s))
+			}
+		case fmt.Stringer:
+			if s := v.String(); r.RedactString(s) != s {
+				a.Value = slog.StringValue(r.RedactString(s))
+			}
+		}
+	default:
+	}
+	return a
+}
+
+// luhnValid checks if digits of the number (ignoring separators) pass Luhn checksum used by card numbers
+func luhnValid(number string) bool {
+	sum, n := 0, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// RedactingHandler masks sensitive values in messages and attributes of log records (see Redactor)
+type RedactingHandler struct {
+	inner    slog.Handler
+	redactor *Redactor
+}
+
+// NewRedactingHandler wraps handler with redaction of log records
+func NewRedactingHandler(inner slog.Handler, redactor *Redactor) *RedactingHandler {
+	return &RedactingHandler{inner: inner, redactor: redactor}
+}
+
+func (h *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *RedactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, h.redactor.RedactString(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redactor.RedactAttr(a))
+		return true
+	})
+	return h.inner.Handle(ctx, redacted)
+}
+
+func (h *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redactor.RedactAttr(a)
+	}
+	return &RedactingHandler{inner: h.inner.WithAttrs(redacted), redactor: h.redactor}
+}
+
+func (h *RedactingHandler) WithGroup(name string) slog.Handler {
+	return &RedactingHandler{inner: h.inner.WithGroup(name), redactor: h.redactor}
+}
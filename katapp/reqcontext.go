@@ -0,0 +1,106 @@
+package katapp
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+// Log attribute keys of request-scoped values
+const (
+	PrincipalKey = "principal"
+	TenantIdKey  = "tenantId"
+	BaggageKey   = "baggage"
+)
+
+type principalContextKey struct{}
+type tenantContextKey struct{}
+type baggageContextKey struct{}
+
+// Principal is an authenticated caller of the request
+type Principal struct {
+	// Subject identifies the caller, e.g. user ID or service account name
+	Subject string
+	Roles   []string
+	Scopes  []string
+}
+
+// HasRole checks if principal has the role
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// HasScope checks if principal has the scope
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// RequestID returns ID of the request (see ContextWithRequestLogger) or empty string if there is none
+func RequestID(ctx context.Context) string {
+	reqID, _ := ctx.Value(RequestIdKey).(string)
+	return reqID
+}
+
+// ContextWithPrincipal returns a new context with authenticated principal,
+// the logger of the context is enriched with principal subject (nil principal leaves context unchanged)
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	if principal == nil {
+		return ctx
+	}
+	ctx = context.WithValue(ctx, principalContextKey{}, principal)
+	return ContextWithLoggerAttrs(ctx, PrincipalKey, principal.Subject)
+}
+
+// PrincipalFrom returns authenticated principal of the context
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return principal, ok
+}
+
+// ContextWithTenant returns a new context with tenant ID, the logger of the context is enriched with it
+func ContextWithTenant(ctx context.Context, tenantID string) context.Context {
+	ctx = context.WithValue(ctx, tenantContextKey{}, tenantID)
+	return ContextWithLoggerAttrs(ctx, TenantIdKey, tenantID)
+}
+
+// TenantFrom returns tenant ID of the context
+func TenantFrom(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenantID, ok
+}
+
+// ContextWithBaggage returns a new context with correlation baggage entries added to the existing ones
+// (they are propagated to outgoing requests, see kathttpc), the logger of the context is enriched with them
+func ContextWithBaggage(ctx context.Context, entries map[string]string) context.Context {
+	if len(entries) == 0 {
+		return ctx
+	}
+	baggage := maps.Clone(Baggage(ctx))
+	if baggage == nil {
+		baggage = make(map[string]string, len(entries))
+	}
+	maps.Copy(baggage, entries)
+	ctx = context.WithValue(ctx, baggageContextKey{}, baggage)
+
+	var attrs []any
+	for _, key := range slices.Sorted(maps.Keys(entries)) {
+		attrs = append(attrs, slog.String(key, entries[key]))
+	}
+	return ContextWithLoggerAttrs(ctx, slog.Group(BaggageKey, attrs...))
+}
+
+// Baggage returns correlation baggage entries of the context (returned map must not be modified)
+func Baggage(ctx context.Context) map[string]string {
+	baggage, _ := ctx.Value(baggageContextKey{}).(map[string]string)
+	return baggage
+}
+
+// ContextWithLoggerAttrs returns a new context with the logger (if there is one) enriched with attributes
+func ContextWithLoggerAttrs(ctx context.Context, args ...any) context.Context {
+	logger, ok := ctx.Value(loggerContextKey{}).(*slog.Logger)
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, loggerContextKey{}, logger.With(args...))
+}
@@ -0,0 +1,61 @@
+package katapp_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestContext_CarriesPrincipalTenantAndBaggage(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	ctx := katapp.ContextWithRequestLogger(context.Background(), logger, "req-1")
+
+	ctx = katapp.ContextWithPrincipal(ctx, &katapp.Principal{
+		Subject: "alice",
+		Roles:   []string{"admin"},
+		Scopes:  []string{"orders:read"},
+	})
+	ctx = katapp.ContextWithTenant(ctx, "acme")
+	ctx = katapp.ContextWithBaggage(ctx, map[string]string{"flow": "checkout"})
+	ctx = katapp.ContextWithBaggage(ctx, map[string]string{"experiment": "b"})
+
+	principal, ok := katapp.PrincipalFrom(ctx)
+	require.True(t, ok)
+	assert.True(t, principal.HasRole("admin"))
+	assert.False(t, principal.HasScope("orders:write"))
+	tenantID, ok := katapp.TenantFrom(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "acme", tenantID)
+	assert.Equal(t, map[string]string{"flow": "checkout", "experiment": "b"}, katapp.Baggage(ctx))
+	assert.Equal(t, "req-1", katapp.RequestID(ctx))
+
+	katapp.Logger(ctx).Info("order created")
+	out := buf.String()
+	for _, attr := range []string{"requestId=req-1", "principal=alice", "tenantId=acme",
+		"baggage.flow=checkout", "baggage.experiment=b"} {
+		assert.Contains(t, out, attr)
+	}
+}
+
+func TestRequestContext_WithoutValues(t *testing.T) {
+	ctx := context.Background()
+	_, ok := katapp.PrincipalFrom(ctx)
+	assert.False(t, ok)
+	_, ok = katapp.TenantFrom(ctx)
+	assert.False(t, ok)
+	assert.Empty(t, katapp.Baggage(ctx))
+	assert.Empty(t, katapp.RequestID(ctx))
+	// context without logger is not enriched
+	assert.NotPanics(t, func() {
+		katapp.ContextWithTenant(ctx, "acme")
+	})
+	// nil principal is not stored
+	_, ok = katapp.PrincipalFrom(katapp.ContextWithPrincipal(ctx, nil))
+	assert.False(t, ok)
+}
@@ -0,0 +1,289 @@
+package katapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// OverlapPolicy defines what happens when job is due while its previous run is still in progress
+type OverlapPolicy int
+
+const (
+	// OverlapSkip skips the run
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue performs the run right after the previous one (at most one run is queued)
+	OverlapQueue
+	// OverlapParallel performs the run concurrently with the previous one
+	OverlapParallel
+)
+
+// LeaderChecker reports if this instance is a leader of the fleet (e.g. katpg.LeaderElector)
+type LeaderChecker interface {
+	IsLeader() bool
+}
+
+// Job is a periodic job of Scheduler
+type Job struct {
+	// Name is a unique name of the job
+	Name string
+	// Schedule defines times of runs, e.g. katapp.Every(time.Minute) or katapp.MustParseCron("0 3 * * *")
+	Schedule Schedule
+	// Run performs the job, context is cancelled when job times out or scheduler is stopped
+	Run func(ctx context.Context) error
+	// Jitter is a maximum random delay added to every run (to spread load of fleet instances)
+	Jitter time.Duration
+	// Overlap defines what happens if job is due while its previous run is still in progress
+	Overlap OverlapPolicy
+	// Timeout limits duration of a single run (no limit if 0)
+	Timeout time.Duration
+	// Leader allows to run job only while this instance is a leader (e.g. katpg.LeaderElector),
+	// runs are skipped on other instances
+	Leader LeaderChecker
+}
+
+// JobRun is a record of job run reported to run history callbacks (see Scheduler.OnRun)
+type JobRun struct {
+	Job string
+	// Scheduled is a time the run was scheduled at (without jitter)
+	Scheduled time.Time
+	// Started and Finished are zero for skipped runs
+	Started  time.Time
+	Finished time.Time
+	// Skipped is a reason why run was skipped ("overlap" or "not leader"), empty if job was performed
+	Skipped string
+	// Err is an error returned by the job (or recovered panic)
+	Err error
+}
+
+// Duration returns duration of the run
+func (r *JobRun) Duration() time.Duration {
+	return r.Finished.Sub(r.Started)
+}
+
+// Reasons of skipped job runs
+const (
+	JobSkippedOverlap   = "overlap"
+	JobSkippedNotLeader = "not leader"
+)
+
+// Scheduler performs periodic jobs. It is a Component, so it can be added to App, e.g.
+//
+//	scheduler := katapp.NewScheduler()
+//	scheduler.Add(katapp.Job{Name: "cleanup", Schedule: katapp.MustParseCron("0 3 * * *"), Run: cleanup,
+//		Leader: leaderElector})
+//	app.Add(scheduler, "postgres")
+type Scheduler struct {
+	mu    sync.Mutex
+	jobs  []*scheduledJob
+	onRun []func(run JobRun)
+
+	cancelLoops context.CancelFunc
+	cancelJobs  context.CancelFunc
+	loops       sync.WaitGroup
+	runs        sync.WaitGroup
+}
+
+type scheduledJob struct {
+	Job
+	mu      sync.Mutex
+	running int
+	queued  *time.Time // scheduled time of queued run
+}
+
+// NewScheduler creates scheduler without jobs
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add adds job to the scheduler, it must be called before scheduler is started.
+// It panics if job is invalid or job with the same name was already added.
+func (s *Scheduler) Add(job Job) {
+	if job.Name == "" || job.Schedule == nil || job.Run == nil {
+		panic("job must have name, schedule and run function")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.Name == job.Name {
+			panic(fmt.Sprintf("job %s was already added", job.Name))
+		}
+	}
+	s.jobs = append(s.jobs, &scheduledJob{Job: job})
+}
+
+// OnRun registers a callback to be invoked after every job run (including skipped runs),
+// e.g. to record run history or metrics
+func (s *Scheduler) OnRun(fn func(run JobRun)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onRun = append(s.onRun, fn)
+}
+
+func (s *Scheduler) Name() string {
+	return "scheduler"
+}
+
+// Start schedules all jobs in background until scheduler is stopped or context is cancelled
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancelLoops != nil {
+		return errors.New("scheduler is already started")
+	}
+	loopCtx, cancelLoops := context.WithCancel(ctx)
+	jobCtx, cancelJobs := context.WithCancel(ctx)
+	s.cancelLoops, s.cancelJobs = cancelLoops, cancelJobs
+	for _, j := range s.jobs {
+		s.loops.Add(1)
+		go s.loop(loopCtx, jobCtx, j)
+	}
+	return nil
+}
+
+// Stop stops scheduling of jobs and waits for running jobs to finish. Jobs that are still
+// running when context is done are cancelled.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancelLoops, cancelJobs := s.cancelLoops, s.cancelJobs
+	s.cancelLoops, s.cancelJobs = nil, nil
+	s.mu.Unlock()
+	if cancelLoops == nil {
+		return nil
+	}
+	cancelLoops()
+	s.loops.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+	defer cancelJobs()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("jobs are still running: %w", ctx.Err())
+	}
+}
+
+// loop waits for scheduled times of the job and dispatches its runs
+func (s *Scheduler) loop(loopCtx context.Context, jobCtx context.Context, j *scheduledJob) {
+	defer s.loops.Done()
+	scheduled := j.Schedule.Next(time.Now())
+	for !scheduled.IsZero() {
+		delay := time.Until(scheduled)
+		if j.Jitter > 0 {
+			delay += rand.N(j.Jitter)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-loopCtx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.dispatch(jobCtx, j, scheduled)
+		// runs missed while scheduler was blocked are not caught up
+		after := scheduled
+		if now := time.Now(); now.After(after) {
+			after = now
+		}
+		scheduled = j.Schedule.Next(after)
+	}
+}
+
+// dispatch performs, queues or skips a run according to leadership and overlap policy of the job
+func (s *Scheduler) dispatch(ctx context.Context, j *scheduledJob, scheduled time.Time) {
+	if j.Leader != nil && !j.Leader.IsLeader() {
+		s.report(ctx, JobRun{Job: j.Name, Scheduled: scheduled, Skipped: JobSkippedNotLeader})
+		return
+	}
+	j.mu.Lock()
+	if j.running > 0 {
+		switch j.Overlap {
+		case OverlapSkip:
+			j.mu.Unlock()
+			s.report(ctx, JobRun{Job: j.Name, Scheduled: scheduled, Skipped: JobSkippedOverlap})
+			return
+		case OverlapQueue:
+			if j.queued != nil {
+				j.mu.Unlock()
+				s.report(ctx, JobRun{Job: j.Name, Scheduled: scheduled, Skipped: JobSkippedOverlap})
+				return
+			}
+			j.queued = &scheduled
+			j.mu.Unlock()
+			return
+		default:
+		}
+	}
+	j.running++
+	j.mu.Unlock()
+
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		for {
+			// leadership could have been lost while queued run was waiting for the previous one
+			if j.Leader != nil && !j.Leader.IsLeader() {
+				s.report(ctx, JobRun{Job: j.Name, Scheduled: scheduled, Skipped: JobSkippedNotLeader})
+			} else {
+				s.report(ctx, s.perform(ctx, j, scheduled))
+			}
+			j.mu.Lock()
+			if j.queued == nil {
+				j.running--
+				j.mu.Unlock()
+				return
+			}
+			scheduled = *j.queued
+			j.queued = nil
+			j.mu.Unlock()
+		}
+	}()
+}
+
+// perform runs the job with timeout and panic recovery
+func (s *Scheduler) perform(ctx context.Context, j *scheduledJob, scheduled time.Time) (run JobRun) {
+	run = JobRun{Job: j.Name, Scheduled: scheduled, Started: time.Now()}
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			run.Err = fmt.Errorf("job %s panicked: %v", j.Name, r)
+			Logger(ctx).WithGroup("katapp.Scheduler").ErrorContext(ctx, "job panicked",
+				"job", j.Name, "panic", r, "stack", string(debug.Stack()))
+		}
+		run.Finished = time.Now()
+	}()
+	run.Err = j.Run(ctx)
+	return run
+}
+
+// report logs run result and invokes run history callbacks
+func (s *Scheduler) report(ctx context.Context, run JobRun) {
+	logger := Logger(ctx).WithGroup("katapp.Scheduler")
+	switch {
+	case run.Skipped != "":
+		logger.DebugContext(ctx, "job run was skipped", "job", run.Job, "reason", run.Skipped)
+	case run.Err != nil:
+		logger.ErrorContext(ctx, "job has failed", "job", run.Job, "error", run.Err, "duration", run.Duration())
+	default:
+		logger.DebugContext(ctx, "job has finished", "job", run.Job, "duration", run.Duration())
+	}
+	s.mu.Lock()
+	callbacks := s.onRun
+	s.mu.Unlock()
+	for _, fn := range callbacks {
+		fn(run)
+	}
+}
@@ -0,0 +1,195 @@
+package katapp_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kattest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type jobRunHistory struct {
+	mu   sync.Mutex
+	runs []katapp.JobRun
+}
+
+func (h *jobRunHistory) record(run katapp.JobRun) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.runs = append(h.runs, run)
+}
+
+func (h *jobRunHistory) find(pred func(run katapp.JobRun) bool) (katapp.JobRun, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, run := range h.runs {
+		if pred(run) {
+			return run, true
+		}
+	}
+	return katapp.JobRun{}, false
+}
+
+func startTestScheduler(t *testing.T, jobs ...katapp.Job) *jobRunHistory {
+	t.Helper()
+	ctx := kattest.AppTestContext()
+	s := katapp.NewScheduler()
+	history := &jobRunHistory{}
+	s.OnRun(history.record)
+	for _, job := range jobs {
+		s.Add(job)
+	}
+	require.NoError(t, s.Start(ctx))
+	t.Cleanup(func() {
+		stopCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		_ = s.Stop(stopCtx)
+	})
+	return history
+}
+
+func TestScheduler_RunsJobsAndRecoversPanics(t *testing.T) {
+	var runs atomic.Int32
+	history := startTestScheduler(t,
+		katapp.Job{Name: "counter", Schedule: katapp.Every(10 * time.Millisecond), Run: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		}},
+		katapp.Job{Name: "panicking", Schedule: katapp.Every(10 * time.Millisecond), Run: func(ctx context.Context) error {
+			panic("boom")
+		}},
+	)
+	assert.Eventually(t, func() bool {
+		return runs.Load() >= 3
+	}, 2*time.Second, 5*time.Millisecond)
+	run, ok := history.find(func(run katapp.JobRun) bool { return run.Job == "panicking" })
+	require.True(t, ok)
+	assert.ErrorContains(t, run.Err, "boom")
+}
+
+func TestScheduler_AppliesTimeout(t *testing.T) {
+	history := startTestScheduler(t, katapp.Job{
+		Name:     "slow",
+		Schedule: katapp.Every(10 * time.Millisecond),
+		Timeout:  20 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	assert.Eventually(t, func() bool {
+		_, ok := history.find(func(run katapp.JobRun) bool {
+			return errors.Is(run.Err, context.DeadlineExceeded)
+		})
+		return ok
+	}, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestScheduler_SkipsOverlappingRuns(t *testing.T) {
+	release := make(chan struct{})
+	var concurrent, maxConcurrent atomic.Int32
+	history := startTestScheduler(t, katapp.Job{
+		Name:     "long",
+		Schedule: katapp.Every(5 * time.Millisecond),
+		Overlap:  katapp.OverlapSkip,
+		Run: func(ctx context.Context) error {
+			n := concurrent.Add(1)
+			defer concurrent.Add(-1)
+			if n > maxConcurrent.Load() {
+				maxConcurrent.Store(n)
+			}
+			<-release
+			return nil
+		},
+	})
+	assert.Eventually(t, func() bool {
+		_, ok := history.find(func(run katapp.JobRun) bool { return run.Skipped == katapp.JobSkippedOverlap })
+		return ok
+	}, 2*time.Second, 5*time.Millisecond)
+	close(release)
+	assert.Equal(t, int32(1), maxConcurrent.Load())
+}
+
+type fakeLeader struct {
+	leader atomic.Bool
+}
+
+func (l *fakeLeader) IsLeader() bool {
+	return l.leader.Load()
+}
+
+func TestScheduler_RunsJobOnlyOnLeader(t *testing.T) {
+	leader := &fakeLeader{}
+	var runs atomic.Int32
+	history := startTestScheduler(t, katapp.Job{
+		Name:     "leader-only",
+		Schedule: katapp.Every(5 * time.Millisecond),
+		Leader:   leader,
+		Run: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+	assert.Eventually(t, func() bool {
+		_, ok := history.find(func(run katapp.JobRun) bool { return run.Skipped == katapp.JobSkippedNotLeader })
+		return ok
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.Zero(t, runs.Load())
+
+	leader.leader.Store(true)
+	assert.Eventually(t, func() bool {
+		return runs.Load() > 0
+	}, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestScheduler_SkipsQueuedRunAfterLosingLeadership(t *testing.T) {
+	leader := &fakeLeader{}
+	leader.leader.Store(true)
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var runs atomic.Int32
+	history := startTestScheduler(t, katapp.Job{
+		Name:     "queued",
+		Schedule: katapp.Every(5 * time.Millisecond),
+		Overlap:  katapp.OverlapQueue,
+		Leader:   leader,
+		Run: func(ctx context.Context) error {
+			runs.Add(1)
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			<-release
+			return nil
+		},
+	})
+	<-started
+	// let the next run be queued, then lose leadership before it starts
+	time.Sleep(30 * time.Millisecond)
+	leader.leader.Store(false)
+	close(release)
+
+	assert.Eventually(t, func() bool {
+		_, ok := history.find(func(run katapp.JobRun) bool { return run.Skipped == katapp.JobSkippedNotLeader })
+		return ok
+	}, 2*time.Second, 5*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, int32(1), runs.Load())
+}
+
+func TestScheduler_AddPanicsOnDuplicateJob(t *testing.T) {
+	s := katapp.NewScheduler()
+	job := katapp.Job{Name: "job", Schedule: katapp.Every(time.Minute), Run: func(ctx context.Context) error {
+		return nil
+	}}
+	s.Add(job)
+	assert.Panics(t, func() {
+		s.Add(job)
+	})
+}
@@ -4,20 +4,21 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 )
 
-// WaitForInterruptSignal waits for interrupt signal to gracefully shut down the server with a timeout.
+// WaitForInterruptSignal waits for interrupt (or SIGTERM) signal to gracefully shut down the server with a timeout.
 // Use a buffered channel to avoid missing signals as recommended for signal.Notify
 func WaitForInterruptSignal(ctx context.Context, timeout time.Duration, shutdown func() error) {
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 	Logger(ctx).InfoContext(ctx, "Interrupt signal has been received")
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 	if err := shutdown(); err != nil {
-		Logger(ctx).WarnContext(ctx, "Shutdown has failed with error: %s", err.Error())
+		Logger(ctx).WarnContext(ctx, "Shutdown has failed", "error", err)
 	}
 	Logger(ctx).InfoContext(ctx, "App is shutdown")
 }
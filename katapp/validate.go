@@ -0,0 +1,364 @@
+package katapp
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const validateTagName = "validate"
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// FieldViolation describes a single field that failed validation
+type FieldViolation struct {
+	// Field is a path to the field, e.g. "server.port" or "database.migrations[0].path"
+	Field string `json:"field"`
+	// Rule is a name of the validation rule that failed, e.g. "required" or "max"
+	Rule string `json:"rule,omitempty"`
+	// Message is a human-readable description of the violation
+	Message string `json:"message"`
+}
+
+// FieldNameFunc returns a name of the struct field as it should appear in violation paths.
+// Empty name means that field is squashed into its parent, "-" means that field must be skipped.
+type FieldNameFunc func(f reflect.StructField) string
+
+// Validate checks struct fields against the rules declared in `validate` tags and returns every
+// violation found. Field paths are built from `mapstructure` tags (or lowercased field names), so
+// they match configuration keys. Supported rules are:
+//
+//	omitempty            skip other rules if value is empty
+//	required             value must not be empty
+//	required_with=F      value must not be empty if sibling field F is not empty
+//	required_without=F   value must not be empty if sibling field F is empty
+//	required_if=F v      value must not be empty if sibling field F equals to v
+//	                     (empty value that is not required skips other rules)
+//	min=n, max=n         bounds for numbers, durations (e.g. min=1s) or length of strings, slices and maps
+//	oneof=a b c          value must be one of space-separated values
+//	url                  value must be an absolute URL
+//	cidr                 value (or every item of a slice) must be a network in CIDR notation, e.g. 10.0.0.0/8
+//	gtfield=F, gtefield=F, ltfield=F, ltefield=F
+//	                     value must be greater (or equal) / less (or equal) than sibling field F
+func Validate(v any) []FieldViolation {
+	return ValidateWithNames(v, configFieldName)
+}
+
+// ValidateWithNames is similar to Validate, but allows to customize how field names appear in violation paths
+func ValidateWithNames(v any, nameOf FieldNameFunc) []FieldViolation {
+	var vs []FieldViolation
+	validateValue(reflect.ValueOf(v), "", nameOf, &vs)
+	return vs
+}
+
+func configFieldName(f reflect.StructField) string {
+	name, opts, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+	if name == "-" {
+		return "-"
+	}
+	if f.Anonymous && strings.Contains(opts, "squash") {
+		return ""
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return strings.ToLower(name)
+}
+
+func joinFieldPath(path, name string) string {
+	if name == "" {
+		return path
+	}
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func validateValue(rv reflect.Value, path string, nameOf FieldNameFunc, vs *[]FieldViolation) {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			sf := rt.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			name := nameOf(sf)
+			if name == "-" {
+				continue
+			}
+			fieldPath := joinFieldPath(path, name)
+			if tag, ok := sf.Tag.Lookup(validateTagName); ok {
+				*vs = append(*vs, checkFieldRules(rv, path, rv.Field(i), fieldPath, tag, nameOf)...)
+			}
+			validateValue(rv.Field(i), fieldPath, nameOf, vs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			validateValue(rv.Index(i), fmt.Sprintf("%s[%d]", path, i), nameOf, vs)
+		}
+	case reflect.Map:
+		keys := rv.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+		})
+		for _, k := range keys {
+			validateValue(rv.MapIndex(k), joinFieldPath(path, fmt.Sprint(k.Interface())), nameOf, vs)
+		}
+	default:
+	}
+}
+
+type validationRule struct {
+	name  string
+	param string
+}
+
+func parseValidationRules(tag string) []validationRule {
+	var rules []validationRule
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, validationRule{name: name, param: param})
+	}
+	return rules
+}
+
+func checkFieldRules(
+	parent reflect.Value,
+	parentPath string,
+	fv reflect.Value,
+	path string,
+	tag string,
+	nameOf FieldNameFunc,
+) []FieldViolation {
+	rules := parseValidationRules(tag)
+	empty := isEmptyValue(fv)
+	if empty && slices.ContainsFunc(rules, func(r validationRule) bool { return r.name == "omitempty" }) {
+		return nil
+	}
+	violation := func(rule, format string, a ...any) []FieldViolation {
+		return []FieldViolation{{Field: path, Rule: rule, Message: fmt.Sprintf(format, a...)}}
+	}
+	sibling := func(rule validationRule, name string) (reflect.Value, string) {
+		sf, ok := parent.Type().FieldByName(name)
+		if !ok {
+			panic(fmt.Sprintf("validation rule %q of field %s refers to unknown field %s", rule.name, path, name))
+		}
+		return parent.FieldByIndex(sf.Index), joinFieldPath(parentPath, nameOf(sf))
+	}
+	// value rules check values of pointers, nil pointers have no value to check
+	value := derefValue(fv)
+	noValue := value.Kind() == reflect.Pointer
+
+	var vs []FieldViolation
+	for _, rule := range rules {
+		if noValue && valueRules[rule.name] {
+			continue
+		}
+		switch rule.name {
+		case "omitempty":
+		case "required":
+			if empty {
+				return violation(rule.name, "is required")
+			}
+		case "required_with":
+			other, otherPath := sibling(rule, rule.param)
+			if !empty {
+				continue
+			}
+			if !isEmptyValue(other) {
+				return violation(rule.name, "is required when %s is set", otherPath)
+			}
+			return nil
+		case "required_without":
+			other, otherPath := sibling(rule, rule.param)
+			if !empty {
+				continue
+			}
+			if isEmptyValue(other) {
+				return violation(rule.name, "is required when %s is not set", otherPath)
+			}
+			return nil
+		case "required_if":
+			name, want, _ := strings.Cut(rule.param, " ")
+			other, otherPath := sibling(rule, name)
+			if !empty {
+				continue
+			}
+			if fmt.Sprint(other.Interface()) == want {
+				return violation(rule.name, "is required when %s is %q", otherPath, want)
+			}
+			return nil
+		case "min", "max":
+			if msg, ok := checkBound(value, rule, path); !ok {
+				vs = append(vs, violation(rule.name, "%s", msg)...)
+			}
+		case "oneof":
+			options := strings.Fields(rule.param)
+			if !slices.Contains(options, fmt.Sprint(value.Interface())) {
+				vs = append(vs, violation(rule.name, "must be one of [%s], got %q",
+					strings.Join(options, ", "), fmt.Sprint(value.Interface()))...)
+			}
+		case "url":
+			if u, err := url.Parse(fmt.Sprint(value.Interface())); err != nil || u.Scheme == "" || u.Host == "" {
+				vs = append(vs, violation(rule.name, "must be an absolute URL, got %q", fmt.Sprint(value.Interface()))...)
+			}
+		case "cidr":
+			values := []string{fmt.Sprint(value.Interface())}
+			if value.Kind() == reflect.Slice {
+				values = values[:0]
+				for i := 0; i < value.Len(); i++ {
+					values = append(values, fmt.Sprint(value.Index(i).Interface()))
+				}
+			}
+			for _, value := range values {
+				if _, err := netip.ParsePrefix(value); err != nil {
+					vs = append(vs, violation(rule.name, "must be a network in CIDR notation, got %q", value)...)
+				}
+			}
+		case "gtfield", "gtefield", "ltfield", "ltefield":
+			other, otherPath := sibling(rule, rule.param)
+			other = derefValue(other)
+			if other.Kind() == reflect.Pointer {
+				// nothing to compare with
+				continue
+			}
+			cmp, ok := compareValues(value, other)
+			if !ok {
+				panic(fmt.Sprintf("validation rule %q of field %s cannot compare %s with %s",
+					rule.name, path, value.Type(), other.Type()))
+			}
+			switch {
+			case rule.name == "gtfield" && cmp <= 0:
+				vs = append(vs, violation(rule.name, "must be greater than %s", otherPath)...)
+			case rule.name == "gtefield" && cmp < 0:
+				vs = append(vs, violation(rule.name, "must be greater than or equal to %s", otherPath)...)
+			case rule.name == "ltfield" && cmp >= 0:
+				vs = append(vs, violation(rule.name, "must be less than %s", otherPath)...)
+			case rule.name == "ltefield" && cmp > 0:
+				vs = append(vs, violation(rule.name, "must be less than or equal to %s", otherPath)...)
+			}
+		default:
+			panic(fmt.Sprintf("unknown validation rule %q of field %s", rule.name, path))
+		}
+	}
+	return vs
+}
+
+// valueRules are rules that check value of the field (rather than its presence)
+var valueRules = map[string]bool{
+	"min": true, "max": true, "oneof": true, "url": true, "cidr": true,
+	"gtfield": true, "gtefield": true, "ltfield": true, "ltefield": true,
+}
+
+// derefValue dereferences non-nil pointers (nil pointer is returned as is)
+func derefValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+// checkBound checks min/max rule and returns a violation message if value is out of bounds
+func checkBound(fv reflect.Value, rule validationRule, path string) (string, bool) {
+	isMin := rule.name == "min"
+	relation := "at most"
+	if isMin {
+		relation = "at least"
+	}
+	inBounds := func(value, bound float64) bool {
+		if isMin {
+			return value >= bound
+		}
+		return value <= bound
+	}
+
+	if fv.Type() == durationType {
+		bound, err := time.ParseDuration(rule.param)
+		if err != nil {
+			panic(fmt.Sprintf("invalid duration %q in validation rule %q of field %s", rule.param, rule.name, path))
+		}
+		d := time.Duration(fv.Int())
+		return fmt.Sprintf("must be %s %s, got %s", relation, bound, d), inBounds(float64(d), float64(bound))
+	}
+
+	bound, err := strconv.ParseFloat(rule.param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("invalid number %q in validation rule %q of field %s", rule.param, rule.name, path))
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		n := len([]rune(fv.String()))
+		return fmt.Sprintf("must be %s %s characters long", relation, rule.param), inBounds(float64(n), bound)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Sprintf("must contain %s %s items", relation, rule.param), inBounds(float64(fv.Len()), bound)
+	default:
+		n, ok := numericValue(fv)
+		if !ok {
+			panic(fmt.Sprintf("validation rule %q is not supported for field %s of type %s", rule.name, path, fv.Type()))
+		}
+		return fmt.Sprintf("must be %s %s, got %v", relation, rule.param, fv.Interface()), inBounds(n, bound)
+	}
+}
+
+func numericValue(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func compareValues(a, b reflect.Value) (int, bool) {
+	if a.Type() == timeType && b.Type() == timeType {
+		return a.Interface().(time.Time).Compare(b.Interface().(time.Time)), true
+	}
+	x, ok1 := numericValue(a)
+	y, ok2 := numericValue(b)
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+	switch {
+	case x < y:
+		return -1, true
+	case x > y:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
@@ -0,0 +1,156 @@
+package katapp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validateTestConfig struct {
+	Server   ServerConfig   `mapstructure:"server"`
+	Database DatabaseConfig `mapstructure:"database"`
+	Api      struct {
+		BaseURL  string        `mapstructure:"baseUrl" validate:"required,url"`
+		Mode     string        `validate:"omitempty,oneof=fast safe"`
+		Timeout  time.Duration `validate:"min=100ms,max=30s"`
+		Token    string        `validate:"required_if=Mode safe"`
+		Username string
+		Password string `validate:"required_with=Username,min=8"`
+	} `mapstructure:"api"`
+}
+
+func validValidateTestConfig() validateTestConfig {
+	var cfg validateTestConfig
+	cfg.Server.Port = 8080
+	cfg.Server.DebugLogNetworks = []string{"127.0.0.1/32", "fd00::/8"}
+	cfg.Api.BaseURL = "https://example.com/api"
+	cfg.Api.Timeout = time.Second
+	return cfg
+}
+
+func violatedFields(vs []FieldViolation) map[string]string {
+	fields := make(map[string]string)
+	for _, v := range vs {
+		fields[v.Field] = v.Rule
+	}
+	return fields
+}
+
+func TestValidate_ValidConfig(t *testing.T) {
+	cfg := validValidateTestConfig()
+	assert.Empty(t, Validate(&cfg))
+}
+
+func TestValidate_ReportsAllViolations(t *testing.T) {
+	var cfg validateTestConfig
+	cfg.Server.Port = 70000
+	cfg.Server.ResponseCompression = "brotli"
+	cfg.Server.DebugLogNetworks = []string{"10.0.0.0/8", "10.0.0.1"}
+	cfg.Database.Pool.MinConns = 10
+	cfg.Database.Pool.MaxConns = 5
+	cfg.Database.Migrations = []DatabaseMigrationConfig{{Service: "svc"}}
+	cfg.Api.BaseURL = "not-a-url"
+	cfg.Api.Mode = "safe"
+	cfg.Api.Timeout = time.Minute
+	cfg.Api.Username = "admin"
+
+	fields := violatedFields(Validate(&cfg))
+	assert.Equal(t, map[string]string{
+		"server.port":                 "max",
+		"server.responsecompression":  "oneof",
+		"server.debuglognetworks":     "cidr",
+		"database.pool.maxconns":      "gtefield",
+		"database.migrations[0].path": "required",
+		"api.baseurl":                 "url",
+		"api.timeout":                 "max",
+		"api.token":                   "required_if",
+		"api.password":                "required_with",
+	}, fields)
+}
+
+func TestValidate_OmitEmptySkipsOtherRules(t *testing.T) {
+	cfg := validValidateTestConfig()
+	cfg.Database.Port = 0
+	cfg.Database.Sslmode = ""
+	assert.Empty(t, Validate(&cfg))
+
+	cfg.Database.Sslmode = "sometimes"
+	fields := violatedFields(Validate(&cfg))
+	assert.Equal(t, map[string]string{"database.sslmode": "oneof"}, fields)
+}
+
+func TestValidate_RequiredArrayMustNotBeZero(t *testing.T) {
+	type request struct {
+		ID [4]byte `validate:"required"`
+	}
+	assert.Equal(t, map[string]string{"id": "required"}, violatedFields(Validate(request{})))
+	assert.Empty(t, Validate(request{ID: [4]byte{1}}))
+}
+
+func TestValidate_ChecksValuesOfPointers(t *testing.T) {
+	type request struct {
+		Limit *int    `validate:"omitempty,min=1,max=100"`
+		Mode  *string `validate:"omitempty,oneof=fast safe"`
+		URL   *string `validate:"omitempty,url"`
+		Start *int
+		End   *int `validate:"omitempty,gtfield=Start"`
+	}
+	limit, mode, link, start, end := 20, "fast", "https://example.com", 5, 10
+	assert.Empty(t, Validate(request{}))
+	assert.Empty(t, Validate(request{Limit: &limit, Mode: &mode, URL: &link, Start: &start, End: &end}))
+	assert.Empty(t, Validate(request{End: &end}))
+
+	limit, mode, link, end = 0, "slow", "example", 1
+	assert.Equal(t, map[string]string{"limit": "min", "mode": "oneof", "url": "url", "end": "gtfield"},
+		violatedFields(Validate(request{Limit: &limit, Mode: &mode, URL: &link, Start: &start, End: &end})))
+}
+
+func TestValidate_UnknownRulePanics(t *testing.T) {
+	type config struct {
+		Name string `validate:"bogus"`
+	}
+	assert.Panics(t, func() {
+		Validate(&config{})
+	})
+}
+
+func TestValidateConfig_ReportsSources(t *testing.T) {
+	tmpDir := t.TempDir()
+	commonFile := filepath.Join(tmpDir, "common.yaml")
+	deploymentFile := filepath.Join(tmpDir, "prod.yaml")
+	require.NoError(t, os.WriteFile(commonFile, []byte("server:\n  port: 70000\napi:\n  baseurl: http://x\n"), 0644))
+	require.NoError(t, os.WriteFile(deploymentFile, []byte("api:\n  timeout: 1m\n"), 0644))
+	t.Setenv("TESTAPP_API_BASEURL", "bad-url")
+
+	prov := newConfigProvenance("testapp")
+	require.NoError(t, prov.addFile(ConfigLayerCommon, commonFile, nil))
+	require.NoError(t, prov.addFile(ConfigLayerDeployment, deploymentFile, nil))
+	prov.addEnv()
+
+	cfg := validValidateTestConfig()
+	cfg.Server.Port = 70000
+	cfg.Api.Timeout = time.Minute
+	cfg.Api.BaseURL = "bad-url"
+	cfg.Api.Username = "admin"
+
+	err := validateConfig(&cfg, prov)
+	var verr *ConfigValidationError
+	require.True(t, errors.As(err, &verr))
+	require.Len(t, verr.Violations, 4)
+
+	sourceByField := make(map[string]string)
+	for _, v := range verr.Violations {
+		sourceByField[v.Field] = v.Source
+	}
+	assert.Equal(t, commonFile+":2", sourceByField["server.port"])
+	assert.Equal(t, deploymentFile+":2", sourceByField["api.timeout"])
+	assert.Equal(t, "env TESTAPP_API_BASEURL", sourceByField["api.baseurl"])
+	assert.Equal(t, "", sourceByField["api.password"])
+	assert.Contains(t, err.Error(), "server.port: must be at most 65535, got 70000 ["+commonFile+":2]")
+	assert.Contains(t, err.Error(), "api.password: is required when api.username is set [not set]")
+}
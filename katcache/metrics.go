@@ -0,0 +1,57 @@
+package katcache
+
+import (
+	"context"
+
+	"github.com/mobiletoly/gokatana/katmetrics"
+)
+
+var _ Cache = (*MetricsCache)(nil)
+
+// MetricsCache records lookup results (hit, miss or error) and errors of the wrapped cache per collection
+type MetricsCache struct {
+	Cache
+	lookups *katmetrics.CounterVec
+	errors  *katmetrics.CounterVec
+}
+
+// WithMetrics wraps cache with recording of its metrics in the registry
+func WithMetrics(cache Cache, reg *katmetrics.Registry) *MetricsCache {
+	return &MetricsCache{
+		Cache: cache,
+		lookups: reg.Counter("katcache_lookups_total",
+			"Number of cache lookups by result (hit, miss or error)", "collection", "result"),
+		errors: reg.Counter("katcache_errors_total",
+			"Number of failed cache operations (get, set or del)", "collection", "operation"),
+	}
+}
+
+func (c *MetricsCache) Get(ctx context.Context, ck CollectionKey, value any) (bool, error) {
+	found, err := c.Cache.Get(ctx, ck, value)
+	switch {
+	case err != nil:
+		c.lookups.With(ck.Name, "error").Inc()
+		c.errors.With(ck.Name, "get").Inc()
+	case found:
+		c.lookups.With(ck.Name, "hit").Inc()
+	default:
+		c.lookups.With(ck.Name, "miss").Inc()
+	}
+	return found, err
+}
+
+func (c *MetricsCache) Set(ctx context.Context, ck CollectionKey, value any) error {
+	err := c.Cache.Set(ctx, ck, value)
+	if err != nil {
+		c.errors.With(ck.Name, "set").Inc()
+	}
+	return err
+}
+
+func (c *MetricsCache) Del(ctx context.Context, ck CollectionKey) error {
+	err := c.Cache.Del(ctx, ck)
+	if err != nil {
+		c.errors.With(ck.Name, "del").Inc()
+	}
+	return err
+}
@@ -0,0 +1,45 @@
+package katcache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/katmetrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingCache struct {
+	Cache
+}
+
+func (c failingCache) Get(context.Context, CollectionKey, any) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestMetricsCache(t *testing.T) {
+	ctx := context.Background()
+	reg := katmetrics.NewRegistry()
+	cache := WithMetrics(NewInMem(), reg)
+	cache.Register(ctx, Collection{Name: "users", ValueType: CollectionValueTypeString, LocalMaxItems: 10})
+
+	var value string
+	assert.NoError(t, cache.Set(ctx, CollectionKey{Name: "users", Key: "1"}, "john"))
+	found, err := cache.Get(ctx, CollectionKey{Name: "users", Key: "1"}, &value)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	found, err = cache.Get(ctx, CollectionKey{Name: "users", Key: "2"}, &value)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	failing := WithMetrics(failingCache{Cache: NewNone()}, reg)
+	_, err = failing.Get(ctx, CollectionKey{Name: "users", Key: "1"}, &value)
+	assert.Error(t, err)
+
+	lookups := reg.Counter("katcache_lookups_total", "", "collection", "result")
+	assert.Equal(t, 1.0, lookups.With("users", "hit").Value())
+	assert.Equal(t, 1.0, lookups.With("users", "miss").Value())
+	assert.Equal(t, 1.0, lookups.With("users", "error").Value())
+	errs := reg.Counter("katcache_errors_total", "", "collection", "operation")
+	assert.Equal(t, 1.0, errs.With("users", "get").Value())
+}
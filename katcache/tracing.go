@@ -0,0 +1,63 @@
+package katcache
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/mobiletoly/gokatana/kattrace"
+)
+
+var _ Cache = (*TracingCache)(nil)
+
+// TracingCache creates child spans of operations of the wrapped cache.
+// Operations performed without a span in the context are not traced.
+type TracingCache struct {
+	Cache
+}
+
+// WithTracing wraps cache with tracing of its operations
+func WithTracing(cache Cache) *TracingCache {
+	return &TracingCache{Cache: cache}
+}
+
+func (c *TracingCache) Get(ctx context.Context, ck CollectionKey, value any) (bool, error) {
+	ctx, span := startSpan(ctx, "get", ck)
+	found, err := c.Cache.Get(ctx, ck, value)
+	if span != nil {
+		span.SetAttrs(slog.Bool("cache.hit", found))
+		span.RecordError(err)
+		span.End()
+	}
+	return found, err
+}
+
+func (c *TracingCache) Set(ctx context.Context, ck CollectionKey, value any) error {
+	ctx, span := startSpan(ctx, "set", ck)
+	err := c.Cache.Set(ctx, ck, value)
+	if span != nil {
+		span.RecordError(err)
+		span.End()
+	}
+	return err
+}
+
+func (c *TracingCache) Del(ctx context.Context, ck CollectionKey) error {
+	ctx, span := startSpan(ctx, "del", ck)
+	err := c.Cache.Del(ctx, ck)
+	if span != nil {
+		span.RecordError(err)
+		span.End()
+	}
+	return err
+}
+
+// startSpan starts span of cache operation if context has a span (nil span is returned otherwise)
+func startSpan(ctx context.Context, operation string, ck CollectionKey) (context.Context, *kattrace.Span) {
+	if !kattrace.SpanContextFrom(ctx).IsValid() {
+		return ctx, nil
+	}
+	return kattrace.Start(ctx, "cache "+operation, kattrace.SpanKindInternal,
+		slog.String("cache.operation", operation),
+		slog.String("cache.collection", ck.Name),
+	)
+}
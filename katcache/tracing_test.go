@@ -0,0 +1,40 @@
+package katcache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/kattrace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracingCache(t *testing.T) {
+	exporter := kattrace.NewInMemoryExporter()
+	tracer := kattrace.NewTracer(exporter, 1)
+	cache := WithTracing(NewInMem())
+	cache.Register(context.Background(), Collection{Name: "users", ValueType: CollectionValueTypeString, LocalMaxItems: 10})
+
+	// operations without a span in the context are not traced
+	var value string
+	_, err := cache.Get(context.Background(), CollectionKey{Name: "users", Key: "1"}, &value)
+	require.NoError(t, err)
+	assert.Empty(t, exporter.Spans())
+
+	ctx, root := tracer.Start(context.Background(), "GET", kattrace.SpanKindServer)
+	require.NoError(t, cache.Set(ctx, CollectionKey{Name: "users", Key: "1"}, "john"))
+	found, err := cache.Get(ctx, CollectionKey{Name: "users", Key: "1"}, &value)
+	require.NoError(t, err)
+	assert.True(t, found)
+	root.End()
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "cache set", spans[0].Name)
+	assert.Equal(t, "cache get", spans[1].Name)
+	assert.Equal(t, root.SpanContext(), spans[1].Parent)
+	collection, _ := spans[1].Attr("cache.collection")
+	assert.Equal(t, "users", collection.String())
+	hit, _ := spans[1].Attr("cache.hit")
+	assert.True(t, hit.Bool())
+}
@@ -0,0 +1,377 @@
+package kathttp
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mobiletoly/gokatana/katapp"
+)
+
+// Tags of request fields bound by BindRequest
+const (
+	PathTag    = "path"
+	QueryTag   = "query"
+	HeaderTag  = "header"
+	DefaultTag = "default"
+)
+
+// ExplodeOption of request field tags makes BindRequest split comma-separated values of slices into items,
+// e.g. `query:"tag,explode"` binds both "?tag=a&tag=b" and "?tag=a,b" (only repeated values are items without it)
+const ExplodeOption = "explode"
+
+// DateLayout is a layout of dates accepted by BindRequest for time.Time fields (in addition to RFC 3339)
+const DateLayout = "2006-01-02"
+
+// DefaultMaxBodySize is a maximum size of JSON request body decoded by BindRequest if server does not
+// set other limit (see katapp.ServerConfig.MaxBodySize)
+const DefaultMaxBodySize = 1 << 20
+
+type maxBodySizeContextKey struct{}
+
+// ContextWithMaxBodySize returns a new context with maximum size of JSON request body decoded by BindRequest
+func ContextWithMaxBodySize(ctx context.Context, size int64) context.Context {
+	return context.WithValue(ctx, maxBodySizeContextKey{}, size)
+}
+
+func maxBodySize(ctx context.Context) int64 {
+	if size, ok := ctx.Value(maxBodySizeContextKey{}).(int64); ok {
+		return size
+	}
+	return DefaultMaxBodySize
+}
+
+var (
+	uuidType            = reflect.TypeOf(uuid.UUID{})
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// BindRequest fills struct pointed by v from the request and validates it. JSON body is decoded first
+// (into fields with `json` tags), then fields with `path`, `query` and `header` tags are set from path
+// parameters (pathValue is provided by adapters), query parameters and headers, e.g.
+//
+//	type ListOrdersRequest struct {
+//		CustomerID uuid.UUID   `path:"customerId"`
+//		Status     OrderStatus `query:"status" validate:"omitempty,oneof=open closed"`
+//		Since      *time.Time  `query:"since"`
+//		Tags       []string    `query:"tag,explode"`
+//		Limit      int         `query:"limit" default:"20" validate:"min=1,max=100"`
+//		TenantID   string      `header:"X-Tenant-ID" validate:"required"`
+//	}
+//
+// Missing values are set from `default` tags. Supported types are strings (including enums based on strings),
+// booleans, numbers, uuid.UUID, time.Time (RFC 3339 time or DateLayout date), time.Duration, types implementing
+// encoding.TextUnmarshaler, pointers to them (nil if value is missing) and slices of them (from repeated values,
+// comma-separated values are split with ExplodeOption). Values that cannot be parsed and rules of `validate` tags
+// (see katapp.Validate) that fail are reported as a single ErrInvalidInput error with violations. JSON body larger
+// than DefaultMaxBodySize (or limit set by ContextWithMaxBodySize) is reported as ErrInvalidInput error
+// that wraps http.MaxBytesError (mapped to 413 Request Entity Too Large).
+//
+// Fields of every struct type are checked once, BindRequest panics if any of them has unsupported type
+// or invalid default value.
+func BindRequest(r *http.Request, pathValue func(name string) string, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("BindRequest expects pointer to struct, got %T", v))
+	}
+	fields := requestFieldsOf(rv.Elem().Type())
+	if err := bindJSONBody(r, v); err != nil {
+		return err
+	}
+
+	query := r.URL.Query()
+	source := func(tag, name string) []string {
+		switch tag {
+		case PathTag:
+			if value := pathValue(name); value != "" {
+				return []string{value}
+			}
+			return nil
+		case QueryTag:
+			return query[name]
+		default:
+			return r.Header.Values(name)
+		}
+	}
+	var vs []katapp.FieldViolation
+	for _, f := range fields {
+		values := source(f.tag, f.name)
+		if len(values) == 0 {
+			if !f.hasDefault {
+				continue
+			}
+			values = []string{f.defaultValue}
+		}
+		if err := setField(rv.Elem().FieldByIndex(f.index), values, f.explode); err != nil {
+			vs = append(vs, katapp.FieldViolation{Field: f.name, Rule: "type", Message: err.Error()})
+		}
+	}
+	// fields that failed to parse are reported only once
+	for _, violation := range katapp.ValidateWithNames(v, requestFieldName) {
+		if !slices.ContainsFunc(vs, func(other katapp.FieldViolation) bool { return other.Field == violation.Field }) {
+			vs = append(vs, violation)
+		}
+	}
+	if len(vs) > 0 {
+		return katapp.NewErr(katapp.ErrInvalidInput, "invalid request").WithViolations(vs...)
+	}
+	return nil
+}
+
+// bindJSONBody decodes JSON body of the request into v (empty body is ignored)
+func bindJSONBody(r *http.Request, v any) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxBodySize(r.Context())))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return katapp.Wrap(katapp.ErrInvalidInput, err, "request body is too large")
+		}
+		return katapp.Wrap(katapp.ErrInternal, err, "failed to read request body")
+	}
+	defer r.Body.Close()
+	if len(body) == 0 {
+		return nil
+	}
+	if !strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		return katapp.NewErr(katapp.ErrInvalidInput, "unsupported content type")
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return katapp.Wrap(katapp.ErrInvalidInput, err, "failed to parse JSON request body")
+	}
+	return nil
+}
+
+// requestTag returns source tag, name and options of request field ("" if field is not bound from path,
+// query or headers)
+func requestTag(f reflect.StructField) (string, string, string) {
+	for _, tag := range []string{PathTag, QueryTag, HeaderTag} {
+		value, ok := f.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+		if name, options, _ := strings.Cut(value, ","); name != "" && name != "-" {
+			return tag, name, options
+		}
+	}
+	return "", "", ""
+}
+
+// requestFieldName names fields in violations by their path, query, header or JSON names
+func requestFieldName(f reflect.StructField) string {
+	if _, name, _ := requestTag(f); name != "" {
+		return name
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch {
+	case name == "-":
+		return "-"
+	case name != "":
+		return name
+	case f.Anonymous:
+		return ""
+	default:
+		return f.Name
+	}
+}
+
+// requestField is a field of request struct bound from path, query or headers
+type requestField struct {
+	index        []int
+	tag          string
+	name         string
+	explode      bool
+	defaultValue string
+	hasDefault   bool
+}
+
+// requestFields caches request fields ([]requestField) of struct types
+var requestFields sync.Map
+
+// requestFieldsOf returns request fields of the struct type, it panics if any of them has unsupported type
+// or invalid default value
+func requestFieldsOf(rt reflect.Type) []requestField {
+	if fields, ok := requestFields.Load(rt); ok {
+		return fields.([]requestField)
+	}
+	var fields []requestField
+	collectRequestFields(rt, nil, &fields)
+	requestFields.Store(rt, fields)
+	return fields
+}
+
+func collectRequestFields(rt reflect.Type, index []int, fields *[]requestField) {
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fieldIndex := append(slices.Clone(index), i)
+		tag, name, options := requestTag(sf)
+		if tag == "" {
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				collectRequestFields(sf.Type, fieldIndex, fields)
+			}
+			continue
+		}
+		if !bindableType(sf.Type) {
+			panic(fmt.Sprintf("BindRequest does not support field %s.%s of type %s", rt, sf.Name, sf.Type))
+		}
+		f := requestField{
+			index:   fieldIndex,
+			tag:     tag,
+			name:    name,
+			explode: slices.Contains(strings.Split(options, ","), ExplodeOption),
+		}
+		f.defaultValue, f.hasDefault = sf.Tag.Lookup(DefaultTag)
+		if f.hasDefault {
+			if err := setField(reflect.New(sf.Type).Elem(), []string{f.defaultValue}, f.explode); err != nil {
+				panic(fmt.Sprintf("BindRequest field %s.%s has invalid default value: %v", rt, sf.Name, err))
+			}
+		}
+		*fields = append(*fields, f)
+	}
+}
+
+// bindableType checks if values of the type can be parsed by setField
+func bindableType(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice && !isTextUnmarshaler(t) {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType || t == durationType || isTextUnmarshaler(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func isTextUnmarshaler(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// setField parses values into the field, slices get all values (comma-separated values are split if explode
+// is set), other fields the first one
+func setField(fv reflect.Value, values []string, explode bool) error {
+	if fv.Kind() == reflect.Slice && !isTextUnmarshaler(fv.Type()) {
+		var items []string
+		for _, value := range values {
+			if !explode {
+				items = append(items, value)
+				continue
+			}
+			for item := range strings.SplitSeq(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		}
+		slice := reflect.MakeSlice(fv.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+	return setValue(fv, values[0])
+}
+
+func setValue(fv reflect.Value, value string) error {
+	if fv.Kind() == reflect.Pointer {
+		elem := reflect.New(fv.Type().Elem())
+		if err := setValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		fv.Set(elem)
+		return nil
+	}
+	switch fv.Type() {
+	case uuidType:
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return fmt.Errorf("must be a UUID, got %q", value)
+		}
+		fv.Set(reflect.ValueOf(id))
+		return nil
+	case timeType:
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			if t, err = time.Parse(DateLayout, value); err != nil {
+				return fmt.Errorf("must be a date (YYYY-MM-DD) or RFC 3339 time, got %q", value)
+			}
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("must be a duration (e.g. 1m30s), got %q", value)
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	if isTextUnmarshaler(fv.Type()) {
+		if err := fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("invalid value %q: %w", value, err)
+		}
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("must be true or false, got %q", value)
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be an integer, got %q", value)
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a non-negative integer, got %q", value)
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a number, got %q", value)
+		}
+		fv.SetFloat(n)
+	default:
+		// types of request fields are checked by requestFieldsOf
+		panic(fmt.Sprintf("BindRequest does not support fields of type %s", fv.Type()))
+	}
+	return nil
+}
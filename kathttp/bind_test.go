@@ -0,0 +1,181 @@
+package kathttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderStatus string
+
+type bindTestRequest struct {
+	CustomerID uuid.UUID     `path:"customerId"`
+	Status     orderStatus   `query:"status" validate:"omitempty,oneof=open closed"`
+	Since      *time.Time    `query:"since"`
+	Active     *bool         `query:"active"`
+	Limit      int           `query:"limit" default:"20" validate:"min=1,max=100"`
+	Page       *int          `query:"page" validate:"omitempty,min=1"`
+	Sort       *string       `query:"sort" validate:"omitempty,oneof=asc desc"`
+	Ratio      float64       `query:"ratio"`
+	Timeout    time.Duration `query:"timeout"`
+	Tags       []string      `query:"tag"`
+	IDs        []uint        `query:"id,explode"`
+	TenantID   string        `header:"X-Tenant-ID"`
+	Name       string        `json:"name"`
+}
+
+func TestBindRequest(t *testing.T) {
+	customerID := uuid.MustParse("0b5c7e35-3f1b-4d52-9b7b-3c5b0b0e0a11")
+	since := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	sinceTime := time.Date(2025, 3, 1, 10, 30, 0, 0, time.UTC)
+	active := false
+	page, sort := 2, "asc"
+
+	tests := []struct {
+		name    string
+		path    map[string]string
+		target  string
+		header  http.Header
+		body    string
+		want    bindTestRequest
+		wantErr []katapp.FieldViolation
+	}{
+		{
+			name: "defaults and nil pointers",
+			want: bindTestRequest{Limit: 20},
+		},
+		{
+			name: "path",
+			path: map[string]string{"customerId": customerID.String()},
+			want: bindTestRequest{CustomerID: customerID, Limit: 20},
+		},
+		{
+			name:   "query scalars",
+			target: "/?status=open&since=2025-03-01&active=false&limit=5&ratio=0.5&timeout=1m30s",
+			want: bindTestRequest{Status: "open", Since: &since, Active: &active, Limit: 5, Ratio: 0.5,
+				Timeout: 90 * time.Second},
+		},
+		{
+			name:   "pointers with value rules",
+			target: "/?page=2&sort=asc",
+			want:   bindTestRequest{Page: &page, Sort: &sort, Limit: 20},
+		},
+		{
+			name:   "RFC 3339 time",
+			target: "/?since=2025-03-01T10:30:00Z",
+			want:   bindTestRequest{Since: &sinceTime, Limit: 20},
+		},
+		{
+			name:   "repeated values without explode",
+			target: "/?tag=a,b&tag=c",
+			want:   bindTestRequest{Tags: []string{"a,b", "c"}, Limit: 20},
+		},
+		{
+			name:   "repeated and comma-separated values with explode",
+			target: "/?id=1,2&id=3",
+			want:   bindTestRequest{IDs: []uint{1, 2, 3}, Limit: 20},
+		},
+		{
+			name:   "header",
+			header: http.Header{"X-Tenant-Id": {"acme"}},
+			want:   bindTestRequest{TenantID: "acme", Limit: 20},
+		},
+		{
+			name:   "JSON body",
+			header: http.Header{"Content-Type": {"application/json"}},
+			body:   `{"name":"order"}`,
+			want:   bindTestRequest{Name: "order", Limit: 20},
+		},
+		{
+			name:   "violations are merged",
+			path:   map[string]string{"customerId": "42"},
+			target: "/?status=lost&limit=abc&id=1,x&page=0&sort=up",
+			wantErr: []katapp.FieldViolation{
+				{Field: "customerId", Rule: "type", Message: `must be a UUID, got "42"`},
+				{Field: "limit", Rule: "type", Message: `must be an integer, got "abc"`},
+				{Field: "id", Rule: "type", Message: `must be a non-negative integer, got "x"`},
+				{Field: "status", Rule: "oneof"},
+				{Field: "page", Rule: "min"},
+				{Field: "sort", Rule: "oneof"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			target := tc.target
+			if target == "" {
+				target = "/"
+			}
+			r := httptest.NewRequest("POST", target, strings.NewReader(tc.body))
+			for k, v := range tc.header {
+				r.Header[k] = v
+			}
+			pathValue := func(name string) string { return tc.path[name] }
+
+			var req bindTestRequest
+			err := kathttp.BindRequest(r, pathValue, &req)
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, req)
+				return
+			}
+			appErr, ok := katapp.AsErr(err)
+			require.True(t, ok)
+			assert.Equal(t, katapp.ErrInvalidInput, appErr.Scope)
+			require.Len(t, appErr.Violations, len(tc.wantErr))
+			for i, want := range tc.wantErr {
+				assert.Equal(t, want.Field, appErr.Violations[i].Field)
+				assert.Equal(t, want.Rule, appErr.Violations[i].Rule)
+				if want.Message != "" {
+					assert.Equal(t, want.Message, appErr.Violations[i].Message)
+				}
+			}
+		})
+	}
+}
+
+func TestBindRequest_BodyLimit(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"`+strings.Repeat("x", 100)+`"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r = r.WithContext(kathttp.ContextWithMaxBodySize(r.Context(), 64))
+
+	var req bindTestRequest
+	err := kathttp.BindRequest(r, func(string) string { return "" }, &req)
+	var maxBytesErr *http.MaxBytesError
+	require.ErrorAs(t, err, &maxBytesErr)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, kathttp.GuessHTTPError(err).HTTPStatusCode)
+}
+
+func TestBindRequest_UnsupportedContentType(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader("name=order"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var req bindTestRequest
+	err := kathttp.BindRequest(r, func(string) string { return "" }, &req)
+	assert.True(t, katapp.IsInvalidInput(err))
+}
+
+func TestBindRequest_ChecksFieldTypesUpFront(t *testing.T) {
+	type unsupported struct {
+		Filter map[string]string `query:"filter"`
+	}
+	type invalidDefault struct {
+		Limit int `query:"limit" default:"many"`
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	pathValue := func(string) string { return "" }
+
+	// fields are checked even if request has no values for them
+	assert.PanicsWithValue(t,
+		"BindRequest does not support field kathttp_test.unsupported.Filter of type map[string]string",
+		func() { _ = kathttp.BindRequest(r, pathValue, &unsupported{}) })
+	assert.Panics(t, func() { _ = kathttp.BindRequest(r, pathValue, &invalidDefault{}) })
+}
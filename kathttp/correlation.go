@@ -0,0 +1,140 @@
+package kathttp
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kattrace"
+)
+
+// Correlation headers propagated between services
+const (
+	RequestIDHeader = "X-Request-ID"
+	TenantIDHeader  = "X-Tenant-ID"
+	// BaggageHeader is W3C baggage header, e.g. "baggage: userId=alice,flow=checkout"
+	BaggageHeader = "baggage"
+)
+
+// Limits of baggage accepted from incoming requests and propagated to outgoing requests
+const (
+	maxBaggageEntries     = 64
+	maxBaggageHeaderBytes = 8192
+	maxBaggageMemberBytes = 256
+)
+
+// CorrelationHeaders returns headers to propagate request ID, tenant ID, baggage and trace context
+// (current span of the context) to outgoing requests. Principal is never propagated, downstream services must authenticate callers.
+func CorrelationHeaders(ctx context.Context) http.Header {
+	headers := make(http.Header)
+	if reqID := katapp.RequestID(ctx); reqID != "" && reqID != "_app_" {
+		headers.Set(RequestIDHeader, reqID)
+	}
+	if tenantID, ok := katapp.TenantFrom(ctx); ok && tenantID != "" {
+		headers.Set(TenantIDHeader, tenantID)
+	}
+	if baggage := katapp.Baggage(ctx); len(baggage) > 0 {
+		headers.Set(BaggageHeader, FormatBaggage(baggage))
+	}
+	if sc := kattrace.SpanContextFrom(ctx); sc.IsValid() {
+		headers.Set(TraceparentHeader, sc.Traceparent())
+		if sc.TraceState != "" {
+			headers.Set(TracestateHeader, sc.TraceState)
+		}
+	}
+	return headers
+}
+
+// BaggageFilter selects baggage entries of incoming requests that are accepted by the service. Baggage
+// is controlled by callers, so only entries with allowed keys are added to request context (and therefore
+// logged with every request log record and propagated to outgoing requests).
+type BaggageFilter struct {
+	keys map[string]struct{}
+}
+
+// NewBaggageFilter creates filter of allowed baggage keys (see ServerConfig.BaggageKeys),
+// no baggage is accepted if there are no allowed keys
+func NewBaggageFilter(keys []string) *BaggageFilter {
+	f := &BaggageFilter{keys: make(map[string]struct{}, len(keys))}
+	for _, key := range keys {
+		f.keys[key] = struct{}{}
+	}
+	return f
+}
+
+// ContextWithRequestBaggage returns a new context with allowed baggage entries of incoming request
+// (see katapp.ContextWithBaggage). Tenant ID header is not trusted implicitly, services must set tenant
+// (katapp.ContextWithTenant) after authenticating the caller.
+func (f *BaggageFilter) ContextWithRequestBaggage(ctx context.Context, r *http.Request) context.Context {
+	if len(f.keys) == 0 {
+		return ctx
+	}
+	baggage := ParseBaggage(r.Header.Get(BaggageHeader))
+	maps.DeleteFunc(baggage, func(key, _ string) bool {
+		_, ok := f.keys[key]
+		return !ok
+	})
+	if len(baggage) == 0 {
+		return ctx
+	}
+	return katapp.ContextWithBaggage(ctx, baggage)
+}
+
+// FormatBaggage formats baggage entries as a value of W3C baggage header. Entries longer than 256 bytes
+// and entries that do not fit into 8192 bytes of the header are dropped.
+func FormatBaggage(baggage map[string]string) string {
+	var sb strings.Builder
+	for _, key := range slices.Sorted(maps.Keys(baggage)) {
+		member := url.PathEscape(key) + "=" + url.PathEscape(baggage[key])
+		if len(member) > maxBaggageMemberBytes || sb.Len()+len(member)+1 > maxBaggageHeaderBytes {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(member)
+	}
+	return sb.String()
+}
+
+// ParseBaggage parses value of W3C baggage header, entry properties are ignored. At most 64 entries
+// are parsed from the first 8192 bytes of the header, entries longer than 256 bytes are dropped.
+func ParseBaggage(header string) map[string]string {
+	if len(header) > maxBaggageHeaderBytes {
+		header = header[:maxBaggageHeaderBytes]
+		if i := strings.LastIndexByte(header, ','); i >= 0 {
+			// drop truncated member
+			header = header[:i]
+		} else {
+			return nil
+		}
+	}
+	var baggage map[string]string
+	for _, member := range strings.Split(header, ",") {
+		member, _, _ = strings.Cut(member, ";")
+		if len(strings.TrimSpace(member)) > maxBaggageMemberBytes {
+			continue
+		}
+		key, value, ok := strings.Cut(member, "=")
+		if !ok {
+			continue
+		}
+		key, errKey := url.PathUnescape(strings.TrimSpace(key))
+		value, errValue := url.PathUnescape(strings.TrimSpace(value))
+		if errKey != nil || errValue != nil || key == "" {
+			continue
+		}
+		if baggage == nil {
+			baggage = make(map[string]string)
+		}
+		if len(baggage) == maxBaggageEntries {
+			break
+		}
+		baggage[key] = value
+	}
+	return baggage
+}
@@ -0,0 +1,49 @@
+package kathttp_test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaggageFilter_AcceptsOnlyAllowedKeys(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(kathttp.BaggageHeader, "tenant=acme,session=abc,secret=xyz")
+
+	ctx := kathttp.NewBaggageFilter([]string{"tenant", "session"}).ContextWithRequestBaggage(context.Background(), r)
+	assert.Equal(t, map[string]string{"tenant": "acme", "session": "abc"}, katapp.Baggage(ctx))
+
+	ctx = kathttp.NewBaggageFilter(nil).ContextWithRequestBaggage(context.Background(), r)
+	assert.Empty(t, katapp.Baggage(ctx))
+}
+
+func TestParseBaggage_Limits(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	assert.Equal(t, map[string]string{"a": "1"}, kathttp.ParseBaggage("a=1,b="+long))
+
+	members := make([]string, 0, 3000)
+	for range 3000 {
+		members = append(members, "k=v")
+	}
+	header := "first=1," + strings.Join(members, ",") + ",last=1"
+	baggage := kathttp.ParseBaggage(header)
+	assert.Equal(t, "1", baggage["first"])
+	assert.NotContains(t, baggage, "last")
+}
+
+func TestFormatBaggage_Limits(t *testing.T) {
+	baggage := map[string]string{"a": "1", "b": strings.Repeat("x", 300)}
+	for i := range 1000 {
+		baggage[fmt.Sprintf("key%04d", i)] = "value"
+	}
+	header := kathttp.FormatBaggage(baggage)
+	assert.LessOrEqual(t, len(header), 8192)
+	assert.True(t, strings.HasPrefix(header, "a=1,"))
+	assert.NotContains(t, header, "b=")
+}
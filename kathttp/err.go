@@ -1,7 +1,8 @@
 package kathttp
 
 import (
-	"errors"
+	"context"
+	"math"
 	"net/http"
 
 	"github.com/mobiletoly/gokatana/katapp"
@@ -9,106 +10,127 @@ import (
 
 // ErrResponse renderer for HTTP failed response
 type ErrResponse struct {
-	Err            error  `json:"-"`               // low-level runtime error
-	HTTPStatusCode int    `json:"-"`               // http response status code
-	StatusText     string `json:"status"`          // user-level status message
-	AppCode        int64  `json:"code,omitempty"`  // application-specific error code
-	ErrorText      string `json:"error,omitempty"` // application-level error message, for debugging
+	Err            error                   `json:"-"`                     // low-level runtime error
+	HTTPStatusCode int                     `json:"-"`                     // http response status code
+	StatusText     string                  `json:"status"`                // user-level status message
+	AppCode        int64                   `json:"code,omitempty"`        // application-specific error code
+	ErrorCode      string                  `json:"errorCode,omitempty"`   // stable application error code
+	ErrorText      string                  `json:"error,omitempty"`       // application-level error message, for debugging
+	Details        map[string]any          `json:"details,omitempty"`     // structured error details
+	Violations     []katapp.FieldViolation `json:"violations,omitempty"`  // invalid input fields
+	Retryable      bool                    `json:"retryable,omitempty"`   // request may succeed if retried
+	RetryAfter     int64                   `json:"retryAfter,omitempty"`  // seconds to wait before retrying
+	Fingerprint    string                  `json:"fingerprint,omitempty"` // stable identifier of internal error origin
+	ErrorRef       string                  `json:"errorRef,omitempty"`    // reference of hidden internal error in logs
+
+	public bool // error text is public even for internal errors (see ErrMapping.Message)
 }
 
-func NewInternalServerErrResponse(err error) *ErrResponse {
-	return &ErrResponse{
-		Err:            err,
-		HTTPStatusCode: http.StatusInternalServerError,
-		StatusText:     "Internal server error",
-		// TODO most likely we don't want to show actual error in case of Internal ServerConfig error to
-		// 	the user as it can potentially reveal sensitive information
-		ErrorText: err.Error(),
-	}
+// errStatusTexts are user-level status messages of error responses
+var errStatusTexts = map[int]string{
+	http.StatusInternalServerError: "Internal server error",
+	http.StatusBadRequest:          "Bad request",
+	http.StatusNotFound:            "Not found",
+	http.StatusConflict:            "Conflict",
+	http.StatusBadGateway:          "Bad Gateway",
+	http.StatusUnauthorized:        "Unauthorized",
+	http.StatusForbidden:           "Forbidden",
 }
 
-func NewBadRequestErrResponse(err error) *ErrResponse {
+// newErrResponse creates error response with the status code reporting text of the error
+// (text of internal errors is hidden when they are written by servers with ErrPolicyHideInternal)
+func newErrResponse(status int, err error) *ErrResponse {
+	statusText, ok := errStatusTexts[status]
+	if !ok {
+		statusText = http.StatusText(status)
+	}
 	return &ErrResponse{
 		Err:            err,
-		HTTPStatusCode: http.StatusBadRequest,
-		StatusText:     "Bad request",
+		HTTPStatusCode: status,
+		StatusText:     statusText,
 		ErrorText:      err.Error(),
 	}
 }
 
+// NewInternalServerErrResponse creates 500 error response, text of the error is hidden according to policy
+// of the server when response is written (see ApplyErrPolicy)
+func NewInternalServerErrResponse(err error) *ErrResponse {
+	errResp := newErrResponse(http.StatusInternalServerError, err)
+	errResp.Fingerprint = katapp.ErrFingerprint(err)
+	return errResp
+}
+
+func NewBadRequestErrResponse(err error) *ErrResponse {
+	return newErrResponse(http.StatusBadRequest, err)
+}
+
 func NewNotFoundErrResponse(err error) *ErrResponse {
-	return &ErrResponse{
-		Err:            err,
-		HTTPStatusCode: http.StatusNotFound,
-		StatusText:     "Not found",
-		ErrorText:      err.Error(),
-	}
+	return newErrResponse(http.StatusNotFound, err)
 }
 
 func NewConflictErrResponse(err error) *ErrResponse {
-	return &ErrResponse{
-		Err:            err,
-		HTTPStatusCode: http.StatusConflict,
-		StatusText:     "Conflict",
-		ErrorText:      err.Error(),
-	}
+	return newErrResponse(http.StatusConflict, err)
 }
 
+// NewBadGatewayErrResponse creates 502 error response, text of the error is hidden according to policy
+// of the server when response is written (see ApplyErrPolicy)
 func NewBadGatewayErrResponse(err error) *ErrResponse {
-	return &ErrResponse{
-		Err:            err,
-		HTTPStatusCode: http.StatusBadGateway,
-		StatusText:     "Bad Gateway",
-		// TODO most likely we don't want to show actual error in case of Internal ServerConfig error to
-		// 	the user as it can potentially reveal sensitive information
-		ErrorText: err.Error(),
-	}
+	return newErrResponse(http.StatusBadGateway, err)
 }
 
 func NewUnauthorizedErrResponse(err error) *ErrResponse {
-	return &ErrResponse{
-		Err:            err,
-		HTTPStatusCode: http.StatusUnauthorized,
-		StatusText:     "Unauthorized",
-		ErrorText:      err.Error(),
-	}
+	return newErrResponse(http.StatusUnauthorized, err)
 }
 
 func NewForbiddenErrResponse(err error) *ErrResponse {
-	return &ErrResponse{
-		Err:            err,
-		HTTPStatusCode: http.StatusForbidden,
-		StatusText:     "Forbidden",
-		ErrorText:      err.Error(),
-	}
+	return newErrResponse(http.StatusForbidden, err)
 }
 
+// GuessHTTPError maps error to error response with the default mapper (see DefaultErrMapper)
 func GuessHTTPError(err error) *ErrResponse {
-	var appErr *katapp.Err
-	var errResp *ErrResponse
-	if errors.As(err, &appErr) {
-		switch appErr.Scope {
-		case katapp.ErrUnknown, katapp.ErrInternal:
-			errResp = NewInternalServerErrResponse(err)
-		case katapp.ErrInvalidInput:
-			errResp = NewBadRequestErrResponse(err)
-		case katapp.ErrNotFound:
-			errResp = NewNotFoundErrResponse(err)
-		case katapp.ErrDuplicate:
-			errResp = NewConflictErrResponse(err)
-		case katapp.ErrFailedExternalService:
-			errResp = NewBadGatewayErrResponse(err)
-		case katapp.ErrUnauthorized:
-			errResp = NewUnauthorizedErrResponse(err)
-		case katapp.ErrNoPermissions:
-			errResp = NewForbiddenErrResponse(err)
-		case katapp.ErrConflict:
-			errResp = NewConflictErrResponse(err)
-		default:
-			errResp = NewInternalServerErrResponse(err)
+	return DefaultErrMapper().Response(err)
+}
+
+// LogHTTPError logs error reported for HTTP request, cause of application error is logged as a separate attribute.
+// Internal errors are logged with their fingerprint and call stack, the stack is logged only once per request
+// (see katapp.MarkErrStackLogged).
+func LogHTTPError(ctx context.Context, r *http.Request, err error, errResp *ErrResponse) {
+	args := []any{"error", err, "URL", r.URL, "method", r.Method, "status", errResp.HTTPStatusCode}
+	if appErr, ok := katapp.AsErr(err); ok && appErr.Cause != nil {
+		args = append(args, "cause", appErr.Cause)
+	}
+	if errResp.ErrorRef != "" {
+		args = append(args, "errorRef", errResp.ErrorRef)
+	}
+	if errResp.Fingerprint != "" {
+		args = append(args, "fingerprint", errResp.Fingerprint)
+		if stack := katapp.ErrStack(err); stack != "" && katapp.MarkErrStackLogged(ctx) {
+			args = append(args, "stack", stack)
 		}
-	} else {
-		errResp = NewInternalServerErrResponse(err)
 	}
-	return errResp
+	katapp.Logger(ctx).ErrorContext(ctx, "HTTP error reported", args...)
+}
+
+// WriteHTTPError writes error response of the error (see GuessHTTPError and WriteErrResponse)
+func WriteHTTPError(w http.ResponseWriter, err error) {
+	WriteErrResponse(w, GuessHTTPError(err))
+}
+
+// LogAndWriteHTTPError logs error (see LogHTTPError) and writes its error response (see WriteHTTPError)
+func LogAndWriteHTTPError(w http.ResponseWriter, r *http.Request, err error) {
+	errResp := ApplyErrPolicy(w, GuessHTTPError(err))
+	LogHTTPError(r.Context(), r, err, errResp)
+	WriteErrResponse(w, errResp)
+}
+
+// applyAppErr carries codes, details, violations and retry hints of application error to the response.
+// Only user-level message of the application error is reported, its cause is never exposed.
+func (r *ErrResponse) applyAppErr(appErr *katapp.Err) {
+	r.AppCode = appErr.AppCode
+	r.ErrorCode = appErr.ErrCode()
+	r.ErrorText = appErr.Msg
+	r.Details = appErr.Details
+	r.Violations = appErr.Violations
+	r.Retryable = appErr.Retryable
+	r.RetryAfter = int64(math.Ceil(appErr.RetryAfter.Seconds()))
 }
@@ -0,0 +1,191 @@
+package kathttp
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mobiletoly/gokatana/katapp"
+)
+
+// ErrPolicy defines how much of internal errors (5xx) is exposed in error responses of a server
+// (see katapp.ServerConfig.InternalErrors and WithErrorReporting)
+type ErrPolicy int
+
+const (
+	// ErrPolicyExpose reports text of all errors (e.g. for development)
+	ErrPolicyExpose ErrPolicy = iota
+	// ErrPolicyHideInternal replaces text of internal errors with InternalErrorMessage and a reference ID
+	// (ErrResponse.ErrorRef), full text of the error is logged with the reference ID (see LogHTTPError)
+	ErrPolicyHideInternal
+)
+
+// Values of katapp.ServerConfig.InternalErrors
+const (
+	InternalErrorsExpose = "expose"
+	InternalErrorsHide   = "hide"
+)
+
+// InternalErrorMessage replaces text of internal errors hidden by ErrPolicyHideInternal
+const InternalErrorMessage = "Internal error, please report the error reference"
+
+// ErrMapping maps errors to error responses
+type ErrMapping struct {
+	// Match reports if mapping applies to the error, e.g. ErrIs(sql.ErrNoRows) or ErrAs[*MyError]()
+	Match func(err error) bool
+	// Status is HTTP status code of the response
+	Status int
+	// Code is a stable error code of the response (error code of katapp.Err is used if empty)
+	Code string
+	// Message is a public message of the response (message of katapp.Err or error text is used if empty)
+	Message string
+}
+
+// ErrIs returns matcher of errors that are (or wrap) the target error
+func ErrIs(target error) func(err error) bool {
+	return func(err error) bool {
+		return errors.Is(err, target)
+	}
+}
+
+// ErrAs returns matcher of errors that are (or wrap) errors of type T
+func ErrAs[T error]() func(err error) bool {
+	return func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}
+}
+
+// ErrMapper maps errors to error responses. Errors are matched against registered mappings in the order
+// they were added, other application errors (katapp.Err) are mapped by their scope and all remaining
+// errors are reported as internal server errors.
+type ErrMapper struct {
+	mu       sync.RWMutex
+	mappings []ErrMapping
+	scopes   map[katapp.ErrScope]int
+}
+
+// NewErrMapper creates mapper with default statuses of error scopes, request bodies exceeding limit
+// of http.MaxBytesReader are reported as 413 Request Entity Too Large
+func NewErrMapper() *ErrMapper {
+	return &ErrMapper{
+		mappings: []ErrMapping{
+			{Match: ErrAs[*http.MaxBytesError](), Status: http.StatusRequestEntityTooLarge},
+		},
+		scopes: map[katapp.ErrScope]int{
+			katapp.ErrUnknown:               http.StatusInternalServerError,
+			katapp.ErrInternal:              http.StatusInternalServerError,
+			katapp.ErrInvalidInput:          http.StatusBadRequest,
+			katapp.ErrNotFound:              http.StatusNotFound,
+			katapp.ErrDuplicate:             http.StatusConflict,
+			katapp.ErrFailedExternalService: http.StatusBadGateway,
+			katapp.ErrUnauthorized:          http.StatusUnauthorized,
+			katapp.ErrNoPermissions:         http.StatusForbidden,
+			katapp.ErrConflict:              http.StatusConflict,
+		},
+	}
+}
+
+var defaultErrMapper atomic.Pointer[ErrMapper]
+
+func init() {
+	defaultErrMapper.Store(NewErrMapper())
+}
+
+// DefaultErrMapper returns mapper used by GuessHTTPError (and therefore by error reporting of all adapters)
+func DefaultErrMapper() *ErrMapper {
+	return defaultErrMapper.Load()
+}
+
+// SetDefaultErrMapper replaces mapper used by GuessHTTPError
+func SetDefaultErrMapper(m *ErrMapper) {
+	defaultErrMapper.Store(m)
+}
+
+// Map adds mapping of errors, it panics if mapping has no matcher or status
+func (m *ErrMapper) Map(mapping ErrMapping) {
+	if mapping.Match == nil || mapping.Status == 0 {
+		panic("error mapping must have matcher and status")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mappings = append(m.mappings, mapping)
+}
+
+// MapScope replaces status of application errors with the scope
+func (m *ErrMapper) MapScope(scope katapp.ErrScope, status int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scopes[scope] = status
+}
+
+// Response maps error to error response
+func (m *ErrMapper) Response(err error) *ErrResponse {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var appErr *katapp.Err
+	isAppErr := errors.As(err, &appErr)
+	var mapping *ErrMapping
+	for i := range m.mappings {
+		if m.mappings[i].Match(err) {
+			mapping = &m.mappings[i]
+			break
+		}
+	}
+
+	status := http.StatusInternalServerError
+	switch {
+	case mapping != nil:
+		status = mapping.Status
+	case isAppErr:
+		if s, ok := m.scopes[appErr.Scope]; ok {
+			status = s
+		}
+	}
+	errResp := newErrResponse(status, err)
+	if isAppErr {
+		errResp.applyAppErr(appErr)
+	}
+	if mapping != nil {
+		if mapping.Code != "" {
+			errResp.ErrorCode = mapping.Code
+		}
+		if mapping.Message != "" {
+			errResp.ErrorText = mapping.Message
+		}
+	}
+	if status == http.StatusInternalServerError {
+		errResp.Fingerprint = katapp.ErrFingerprint(err)
+	}
+	// message of the mapping is public, it is not hidden by ErrPolicyHideInternal
+	errResp.public = mapping != nil && mapping.Message != ""
+	return errResp
+}
+
+// ApplyErrPolicy hides text of internal error response if required by the error policy of the server
+// (see WithErrorReporting). Response is hidden only once, so the error can be logged with its reference
+// (see LogHTTPError) before the response is written.
+func ApplyErrPolicy(w http.ResponseWriter, errResp *ErrResponse) *ErrResponse {
+	if errResp.public || errResp.ErrorRef != "" || errResp.HTTPStatusCode < http.StatusInternalServerError {
+		return errResp
+	}
+	if ew := errorWriterOf(w); ew != nil && ew.policy == ErrPolicyHideInternal {
+		errResp.hideInternal()
+	}
+	return errResp
+}
+
+// hideInternal replaces text of internal error with generic message and reference ID
+func (r *ErrResponse) hideInternal() {
+	var ref [8]byte
+	for i := range ref {
+		ref[i] = byte(rand.Uint32())
+	}
+	r.ErrorRef = hex.EncodeToString(ref[:])
+	r.ErrorText = InternalErrorMessage
+	r.Details = nil
+}
@@ -0,0 +1,123 @@
+package kathttp_test
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type quotaError struct{ limit int }
+
+func (e *quotaError) Error() string {
+	return fmt.Sprintf("quota of %d requests exceeded", e.limit)
+}
+
+func TestErrMapper_LookupOrder(t *testing.T) {
+	m := kathttp.NewErrMapper()
+	m.Map(kathttp.ErrMapping{Match: kathttp.ErrIs(sql.ErrNoRows), Status: http.StatusNotFound, Code: "row_missing"})
+	m.Map(kathttp.ErrMapping{Match: kathttp.ErrAs[*quotaError](), Status: http.StatusTooManyRequests})
+	m.Map(kathttp.ErrMapping{Match: kathttp.ErrIs(sql.ErrNoRows), Status: http.StatusGone})
+	m.MapScope(katapp.ErrConflict, http.StatusPreconditionFailed)
+
+	// the first matching mapping wins
+	errResp := m.Response(sql.ErrNoRows)
+	assert.Equal(t, http.StatusNotFound, errResp.HTTPStatusCode)
+	assert.Equal(t, "row_missing", errResp.ErrorCode)
+
+	// mappings take precedence over scopes of application errors
+	errResp = m.Response(katapp.Wrap(katapp.ErrInvalidInput, &quotaError{limit: 10}, "too many requests"))
+	assert.Equal(t, http.StatusTooManyRequests, errResp.HTTPStatusCode)
+	assert.Equal(t, "too many requests", errResp.ErrorText)
+
+	// application errors are mapped by (replaced) scope statuses
+	errResp = m.Response(katapp.NewErr(katapp.ErrConflict, "version mismatch"))
+	assert.Equal(t, http.StatusPreconditionFailed, errResp.HTTPStatusCode)
+	assert.Equal(t, "version mismatch", errResp.ErrorText)
+	errResp = m.Response(katapp.NewErr(katapp.ErrNotFound, "user not found"))
+	assert.Equal(t, http.StatusNotFound, errResp.HTTPStatusCode)
+
+	// remaining errors are internal
+	errResp = m.Response(errors.New("connection reset"))
+	assert.Equal(t, http.StatusInternalServerError, errResp.HTTPStatusCode)
+	assert.Equal(t, "connection reset", errResp.ErrorText)
+	assert.NotEmpty(t, errResp.Fingerprint)
+}
+
+func TestErrMapper_WrappedErrors(t *testing.T) {
+	m := kathttp.NewErrMapper()
+	m.Map(kathttp.ErrMapping{Match: kathttp.ErrIs(sql.ErrNoRows), Status: http.StatusNotFound, Message: "record not found"})
+	m.Map(kathttp.ErrMapping{Match: kathttp.ErrAs[*quotaError](), Status: http.StatusTooManyRequests})
+
+	errResp := m.Response(fmt.Errorf("load user: %w", sql.ErrNoRows))
+	assert.Equal(t, http.StatusNotFound, errResp.HTTPStatusCode)
+	assert.Equal(t, "record not found", errResp.ErrorText)
+
+	errResp = m.Response(fmt.Errorf("call api: %w", &quotaError{limit: 5}))
+	assert.Equal(t, http.StatusTooManyRequests, errResp.HTTPStatusCode)
+	assert.Equal(t, "call api: quota of 5 requests exceeded", errResp.ErrorText)
+
+	errResp = m.Response(fmt.Errorf("save user: %w", katapp.NewErr(katapp.ErrDuplicate, "email is taken").WithCode("email_taken")))
+	assert.Equal(t, http.StatusConflict, errResp.HTTPStatusCode)
+	assert.Equal(t, "email_taken", errResp.ErrorCode)
+	assert.Equal(t, "email is taken", errResp.ErrorText)
+}
+
+func TestLogAndWriteHTTPError_HidePolicy(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	cfg := &katapp.ServerConfig{InternalErrors: kathttp.InternalErrorsHide}
+
+	serve := func(err error) (*httptest.ResponseRecorder, kathttp.ErrResponse) {
+		logs.Reset()
+		r := httptest.NewRequest("GET", "/users", nil)
+		r = r.WithContext(katapp.ContextWithRequestLogger(r.Context(), logger, "req-1"))
+		rec := httptest.NewRecorder()
+		kathttp.LogAndWriteHTTPError(kathttp.WithErrorReporting(rec, r, cfg), r, err)
+		var errResp kathttp.ErrResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errResp))
+		return rec, errResp
+	}
+
+	rec, errResp := serve(errors.New("pq: password authentication failed for user app"))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, kathttp.InternalErrorMessage, errResp.ErrorText)
+	require.NotEmpty(t, errResp.ErrorRef)
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+	assert.Equal(t, "pq: password authentication failed for user app", record["error"])
+	assert.Equal(t, errResp.ErrorRef, record["errorRef"])
+
+	// errors below 500 are not hidden
+	rec, errResp = serve(katapp.NewErr(katapp.ErrNotFound, "user not found"))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "user not found", errResp.ErrorText)
+	assert.Empty(t, errResp.ErrorRef)
+
+	// cause of application error is logged separately and never reported
+	cause := errors.New(`duplicate key value violates unique constraint "users_email_key"`)
+	rec, errResp = serve(katapp.Wrap(katapp.ErrDuplicate, cause, "user: duplicate data"))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "user: duplicate data", errResp.ErrorText)
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+	assert.Equal(t, "user: duplicate data", record["error"])
+	assert.Equal(t, cause.Error(), record["cause"])
+
+	// policy belongs to the server, other servers expose internal errors
+	r := httptest.NewRequest("GET", "/users", nil)
+	rec = httptest.NewRecorder()
+	kathttp.WriteHTTPError(kathttp.WithErrorReporting(rec, r, &katapp.ServerConfig{}), errors.New("disk is full"))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errResp))
+	assert.Equal(t, "disk is full", errResp.ErrorText)
+	assert.Empty(t, errResp.ErrorRef)
+}
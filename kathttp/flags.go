@@ -0,0 +1,22 @@
+package kathttp
+
+import (
+	"net/http"
+
+	"github.com/mobiletoly/gokatana/katapp"
+)
+
+// RequireFlag returns middleware that serves routes only if feature flag is enabled for the request
+// context (requests are rejected with 404 Not Found otherwise). It can be used with kathttp_std and
+// kathttp_chi, principal and tenant must be added to the context before it (see katapp.ContextWithPrincipal).
+func RequireFlag(flags *katapp.Flags, name string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !flags.Enabled(r.Context(), name) {
+				WriteErrResponse(w, GuessHTTPError(katapp.NewErr(katapp.ErrNotFound, "not found")))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package kathttp
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/mobiletoly/gokatana/katapp"
+)
+
+const (
+	// LivenessPath is a path of liveness probe endpoint
+	LivenessPath = "/healthz"
+	// ReadinessPath is a path of readiness probe endpoint
+	ReadinessPath = "/readyz"
+)
+
+// LivenessHandler serves liveness probe of the health registry
+func LivenessHandler(registry *katapp.HealthRegistry) http.Handler {
+	return healthHandler(registry.Liveness)
+}
+
+// ReadinessHandler serves readiness probe of the health registry
+func ReadinessHandler(registry *katapp.HealthRegistry) http.Handler {
+	return healthHandler(registry.Readiness)
+}
+
+// healthHandler writes health report as JSON with 200 status code if application is healthy. If any
+// of critical checks has failed, 503 error response is written with the report as its details.
+func healthHandler(probe func(ctx context.Context) *katapp.HealthReport) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// probes must always reach the application
+		w.Header().Set("Cache-Control", "no-store")
+		report := probe(r.Context())
+		if !report.Healthy() {
+			errResp := NewStatusErrResponse(http.StatusServiceUnavailable, "application is not healthy")
+			errResp.Details = map[string]any{"status": report.Status, "checks": report.Checks}
+			WriteErrResponse(w, errResp)
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	})
+}
@@ -0,0 +1,21 @@
+package kathttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthHandler_DisablesCaching(t *testing.T) {
+	registry := katapp.NewHealthRegistry(0)
+	for _, handler := range []http.Handler{kathttp.LivenessHandler(registry), kathttp.ReadinessHandler(registry)} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, kathttp.ReadinessPath, nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	}
+}
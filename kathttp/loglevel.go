@@ -0,0 +1,126 @@
+package kathttp
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+
+	"github.com/mobiletoly/gokatana/katapp"
+)
+
+const (
+	// LogLevelPath is a path of admin endpoint to view and change log levels
+	LogLevelPath = "/admin/loglevel"
+	// DebugLogHeader is a request header to enable debug logging of a single request ("X-Debug-Log: 1")
+	DebugLogHeader = "X-Debug-Log"
+)
+
+// LogLevels is a representation of log levels served by LogLevelHandler
+type LogLevels struct {
+	Level  slog.Level            `json:"level"`
+	Groups map[string]slog.Level `json:"groups"`
+}
+
+// LogLevelChange is a request to change log level. Global level is changed if Group is empty,
+// override of the group level is removed if Level is empty.
+type LogLevelChange struct {
+	Group string `json:"group,omitempty"`
+	Level string `json:"level,omitempty"`
+}
+
+// LogLevelHandler serves log levels of the controller on GET (and HEAD) requests and changes them on PUT requests
+// (see LogLevelChange), other methods are not allowed. The endpoint must be exposed only to administrators,
+// e.g. on internal port.
+func LogLevelHandler(levels *katapp.LevelController) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut:
+			if err := changeLogLevel(r, levels); err != nil {
+				WriteErrResponse(w, GuessHTTPError(err))
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			WriteErrResponse(w, NewStatusErrResponse(http.StatusMethodNotAllowed, ""))
+			return
+		}
+		writeJSON(w, http.StatusOK, LogLevels{Level: levels.Level(), Groups: levels.GroupLevels()})
+	})
+}
+
+func changeLogLevel(r *http.Request, levels *katapp.LevelController) error {
+	var change LogLevelChange
+	if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+		return katapp.Wrap(katapp.ErrInvalidInput, err, "failed to parse log level change")
+	}
+	if change.Group != "" && change.Level == "" {
+		levels.ResetGroupLevel(change.Group)
+		return nil
+	}
+	if change.Level == "" {
+		return katapp.NewErr(katapp.ErrInvalidInput, "log level is required")
+	}
+	level, err := katapp.ParseLogLevel(change.Level)
+	if err != nil {
+		return katapp.Wrap(katapp.ErrInvalidInput, err, "invalid log level %q", change.Level)
+	}
+	if change.Group == "" {
+		levels.SetLevel(level)
+	} else {
+		levels.SetGroupLevel(change.Group, level)
+	}
+	katapp.Logger(r.Context()).InfoContext(r.Context(), "log level was changed",
+		"group", change.Group, "level", level)
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// DebugLogFilter checks if request asks for debug logging with DebugLogHeader and comes from
+// trusted networks. Remote address of the connection is checked, forwarding headers are not trusted.
+type DebugLogFilter struct {
+	networks []netip.Prefix
+}
+
+// NewDebugLogFilter creates filter for trusted networks in CIDR notation (see ServerConfig.DebugLogNetworks),
+// invalid networks are ignored. Debug logging cannot be requested if there are no trusted networks.
+func NewDebugLogFilter(networks []string) *DebugLogFilter {
+	f := &DebugLogFilter{}
+	for _, network := range networks {
+		if prefix, err := netip.ParsePrefix(network); err == nil {
+			f.networks = append(f.networks, prefix.Masked())
+		}
+	}
+	return f
+}
+
+// DebugLogRequested checks if request asks for debug logging and is allowed to
+func (f *DebugLogFilter) DebugLogRequested(r *http.Request) bool {
+	if len(f.networks) == 0 || r.Header.Get(DebugLogHeader) != "1" {
+		return false
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, network := range f.networks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
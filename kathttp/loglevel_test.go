@@ -0,0 +1,47 @@
+package kathttp_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+	"github.com/mobiletoly/gokatana/kattest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogLevelHandler_Methods(t *testing.T) {
+	levels := katapp.NewLevelController(slog.LevelInfo)
+	handler := kathttp.LogLevelHandler(levels)
+	serve := func(method string, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(method, kathttp.LogLevelPath, strings.NewReader(body))
+		handler.ServeHTTP(rec, r.WithContext(kattest.AppTestContext()))
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var got kathttp.LogLevels
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, slog.LevelInfo, got.Level)
+	assert.Equal(t, http.StatusOK, serve(http.MethodHead, "").Code)
+
+	rec = serve(http.MethodPut, `{"level":"debug"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, slog.LevelDebug, levels.Level())
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, `{"level":"loud"}`).Code)
+
+	for _, method := range []string{http.MethodPost, http.MethodDelete} {
+		rec = serve(method, `{"level":"error"}`)
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		assert.Equal(t, "GET, PUT", rec.Header().Get("Allow"))
+	}
+	assert.Equal(t, slog.LevelDebug, levels.Level())
+}
@@ -0,0 +1,54 @@
+package kathttp
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mobiletoly/gokatana/katmetrics"
+)
+
+const (
+	// MetricsPath is a path of metrics endpoint (Prometheus text exposition format)
+	MetricsPath = "/metrics"
+	// UnmatchedRoute is a route label of requests that did not match any route
+	UnmatchedRoute = "unmatched"
+)
+
+// HTTPMetrics records number and latency of served HTTP requests per method, route pattern and status
+type HTTPMetrics struct {
+	requests *katmetrics.CounterVec
+	duration *katmetrics.HistogramVec
+}
+
+// NewHTTPMetrics registers metrics of served HTTP requests in the registry
+func NewHTTPMetrics(reg *katmetrics.Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: reg.Counter("http_server_requests_total",
+			"Number of served HTTP requests", "method", "route", "status"),
+		duration: reg.Histogram("http_server_request_duration_seconds",
+			"Latency of served HTTP requests", nil, "method", "route", "status"),
+	}
+}
+
+// Observe records served request. Route must be a route pattern (not an actual path) to keep
+// the number of series bounded, use UnmatchedRoute for requests without route.
+func (m *HTTPMetrics) Observe(method string, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = UnmatchedRoute
+	}
+	labels := []string{MetricsMethod(method), route, strconv.Itoa(status)}
+	m.requests.With(labels...).Inc()
+	m.duration.With(labels...).Observe(duration.Seconds())
+}
+
+// MetricsMethod returns method as a metric label, non-standard methods are reported as "OTHER"
+func MetricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "OTHER"
+	}
+}
@@ -0,0 +1,140 @@
+package kathttp
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"github.com/mobiletoly/gokatana/katapp"
+)
+
+// Formats of error responses (see katapp.ServerConfig.ErrorFormat)
+const (
+	// ErrorFormatJSON writes ErrResponse as "application/json" (default)
+	ErrorFormatJSON = "json"
+	// ErrorFormatProblem writes Problem as "application/problem+json" (RFC 9457)
+	ErrorFormatProblem = "problem"
+)
+
+// ProblemContentType is a content type of RFC 9457 problem details
+const ProblemContentType = "application/problem+json"
+
+// Problem is an error response in RFC 9457 format. Code, violations and other members of ErrResponse
+// are reported as extension members.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"` // ID of the request
+
+	Code        string                  `json:"code,omitempty"`
+	AppCode     int64                   `json:"appCode,omitempty"`
+	Details     map[string]any          `json:"details,omitempty"`
+	Violations  []katapp.FieldViolation `json:"violations,omitempty"`
+	Retryable   bool                    `json:"retryable,omitempty"`
+	RetryAfter  int64                   `json:"retryAfter,omitempty"`
+	Fingerprint string                  `json:"fingerprint,omitempty"`
+	ErrorRef    string                  `json:"errorRef,omitempty"`
+}
+
+// Problem converts error response to RFC 9457 problem details, instance is an ID of the request
+func (r *ErrResponse) Problem(instance string) *Problem {
+	return &Problem{
+		Type:        "about:blank",
+		Title:       http.StatusText(r.HTTPStatusCode),
+		Status:      r.HTTPStatusCode,
+		Detail:      r.ErrorText,
+		Instance:    instance,
+		Code:        r.ErrorCode,
+		AppCode:     r.AppCode,
+		Details:     r.Details,
+		Violations:  r.Violations,
+		Retryable:   r.Retryable,
+		RetryAfter:  r.RetryAfter,
+		Fingerprint: r.Fingerprint,
+		ErrorRef:    r.ErrorRef,
+	}
+}
+
+// NewStatusErrResponse creates error response with the status code, e.g. for errors reported by routers
+// (404 and 405) or recovered panics
+func NewStatusErrResponse(status int, message string) *ErrResponse {
+	return &ErrResponse{
+		HTTPStatusCode: status,
+		StatusText:     http.StatusText(status),
+		ErrorText:      message,
+	}
+}
+
+// errorWriter carries error reporting settings of the server and ID of the request to error reporting
+type errorWriter struct {
+	http.ResponseWriter
+	format    string
+	policy    ErrPolicy
+	requestID string
+}
+
+// Unwrap allows http.ResponseController to access the underlying ResponseWriter
+func (w *errorWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush forwards to the underlying ResponseWriter, so handlers can stream responses (e.g. server-sent events)
+func (w *errorWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack forwards to the underlying ResponseWriter, so handlers can take over the connection (e.g. websockets).
+// It returns http.ErrNotSupported if the underlying ResponseWriter cannot be hijacked.
+func (w *errorWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// WithErrorReporting returns response writer of the request that makes WriteErrResponse use error format and
+// error policy of the server (see katapp.ServerConfig.ErrorFormat and InternalErrors). Servers wrap response
+// writers of all requests, so handlers can report errors without knowing the settings.
+func WithErrorReporting(w http.ResponseWriter, r *http.Request, cfg *katapp.ServerConfig) http.ResponseWriter {
+	requestID := katapp.RequestID(r.Context())
+	if requestID == "" {
+		requestID = w.Header().Get(RequestIDHeader)
+	}
+	policy := ErrPolicyExpose
+	if cfg.InternalErrors == InternalErrorsHide {
+		policy = ErrPolicyHideInternal
+	}
+	return &errorWriter{ResponseWriter: w, format: cfg.ErrorFormat, policy: policy, requestID: requestID}
+}
+
+// errorWriterOf finds error reporting settings of the response writer (see WithErrorReporting),
+// writers wrapping it must implement Unwrap
+func errorWriterOf(w http.ResponseWriter) *errorWriter {
+	for {
+		switch ww := w.(type) {
+		case *errorWriter:
+			return ww
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = ww.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// WriteErrResponse writes error response in error format of the server (see WithErrorReporting), ErrResponse
+// is written as JSON by default. Text of internal errors is hidden if required by error policy of the server.
+func WriteErrResponse(w http.ResponseWriter, errResp *ErrResponse) {
+	errResp = ApplyErrPolicy(w, errResp)
+	var body any = errResp
+	contentType := "application/json"
+	if ew := errorWriterOf(w); ew != nil && ew.format == ErrorFormatProblem {
+		body = errResp.Problem(ew.requestID)
+		contentType = ProblemContentType
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(errResp.HTTPStatusCode)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,37 @@
+package kathttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithErrorReporting_PreservesFlusher(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := kathttp.WithErrorReporting(rec, httptest.NewRequest("GET", "/", nil), &katapp.ServerConfig{})
+	flusher, ok := w.(http.Flusher)
+	require.True(t, ok)
+	flusher.Flush()
+	assert.True(t, rec.Flushed)
+
+	_, _, err := w.(http.Hijacker).Hijack()
+	assert.ErrorIs(t, err, http.ErrNotSupported)
+}
+
+func TestWriteErrResponse_Problem(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	rec.Header().Set(kathttp.RequestIDHeader, "req-1")
+	w := kathttp.WithErrorReporting(rec, r, &katapp.ServerConfig{ErrorFormat: kathttp.ErrorFormatProblem})
+	kathttp.WriteErrResponse(w, kathttp.NewStatusErrResponse(http.StatusConflict, "already exists"))
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, kathttp.ProblemContentType, rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"type":"about:blank","title":"Conflict","status":409,"detail":"already exists","instance":"req-1"}`,
+		rec.Body.String())
+}
@@ -0,0 +1,52 @@
+package kathttp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kattrace"
+)
+
+// W3C Trace Context headers
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+// StartServerSpan starts server span of the request continuing trace of the caller ("traceparent" header),
+// the logger of the returned context is enriched with trace and span IDs
+func StartServerSpan(ctx context.Context, r *http.Request) (context.Context, *kattrace.Span) {
+	if traceparent := r.Header.Get(TraceparentHeader); traceparent != "" {
+		if sc, err := kattrace.ParseTraceparent(traceparent); err == nil {
+			sc.TraceState = r.Header.Get(TracestateHeader)
+			ctx = kattrace.ContextWithRemoteSpanContext(ctx, sc)
+		}
+	}
+	ctx, span := kattrace.Start(ctx, r.Method, kattrace.SpanKindServer,
+		slog.String("http.request.method", r.Method),
+		slog.String("url.path", r.URL.Path),
+	)
+	sc := span.SpanContext()
+	ctx = katapp.ContextWithLoggerAttrs(ctx,
+		kattrace.TraceIdKey, sc.TraceID.String(),
+		kattrace.SpanIdKey, sc.SpanID.String(),
+	)
+	return ctx, span
+}
+
+// EndServerSpan names the span by route pattern of the request (empty if request did not match any route)
+// and ends it with the response status, server errors (5xx) mark the span as failed
+func EndServerSpan(span *kattrace.Span, method string, route string, status int) {
+	if route != "" {
+		span.SetName(method + " " + route)
+		span.SetAttrs(slog.String("http.route", route))
+	}
+	span.SetAttrs(slog.Int("http.response.status_code", status))
+	if status >= http.StatusInternalServerError {
+		span.RecordError(fmt.Errorf("HTTP status %d", status))
+	}
+	span.End()
+}
@@ -0,0 +1,64 @@
+package kathttp_chi
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindTestRequest struct {
+	ID     int      `path:"id"`
+	Tags   []string `query:"tag,explode"`
+	Tenant string   `header:"X-Tenant-ID" validate:"required"`
+	Name   string   `json:"name"`
+}
+
+func TestBindRequest(t *testing.T) {
+	cfg := &katapp.ServerConfig{MaxBodySize: 64}
+	handler := newHandler(context.Background(), cfg, slog.New(slog.DiscardHandler), func(mux *chi.Mux) http.Handler {
+		mux.Post("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+			var req bindTestRequest
+			if err := BindRequest(r, &req); err != nil {
+				ReportHTTPError(w, err)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(req)
+		})
+		return mux
+	})
+	serve := func(body string, tenant string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/items/7?tag=a,b&tag=c", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		if tenant != "" {
+			r.Header.Set("X-Tenant-ID", tenant)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := serve(`{"name":"box"}`, "acme")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var req bindTestRequest
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &req))
+	assert.Equal(t, bindTestRequest{ID: 7, Tags: []string{"a", "b", "c"}, Tenant: "acme", Name: "box"}, req)
+
+	rec = serve(`{"name":"box"}`, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var errResp kathttp.ErrResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errResp))
+	assert.Equal(t, "X-Tenant-ID", errResp.Violations[0].Field)
+
+	rec = serve(`{"name":"`+strings.Repeat("x", 100)+`"}`, "acme")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
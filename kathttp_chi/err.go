@@ -1,21 +1,78 @@
 package kathttp_chi
 
 import (
-	"encoding/json"
-	"github.com/mobiletoly/gokatana/kathttp"
 	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
 )
 
 // ReportHTTPError writes an error response to the HTTP response writer
+// (in error format of the server, see katapp.ServerConfig.ErrorFormat)
 func ReportHTTPError(w http.ResponseWriter, err error) {
-	errResp := kathttp.GuessHTTPError(err)
+	kathttp.WriteHTTPError(w, err)
+}
 
-	// Set content type and status code
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(errResp.HTTPStatusCode)
+// LogAndReportHTTPError logs error (internal errors are logged with their call stack and fingerprint)
+// and writes an error response to the HTTP response writer
+func LogAndReportHTTPError(w http.ResponseWriter, r *http.Request, err error) {
+	kathttp.LogAndWriteHTTPError(w, r, err)
+}
+
+// errorReportingMiddleware makes error responses of handlers use the error format and error policy of the server
+func errorReportingMiddleware(cfg *katapp.ServerConfig) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(kathttp.WithErrorReporting(w, r, cfg), r)
+		})
+	}
+}
+
+// notFoundHandler reports requests without route as error responses
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	kathttp.WriteErrResponse(w, kathttp.NewStatusErrResponse(http.StatusNotFound, ""))
+}
+
+// methodNotAllowedHandler reports requests with unsupported method as error responses,
+// methods supported by the route are listed in "Allow" header
+func methodNotAllowedHandler(mux *chi.Mux) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.RawPath
+		if path == "" {
+			path = r.URL.Path
+		}
+		for _, method := range []string{
+			http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+			http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+		} {
+			if mux.Match(chi.NewRouteContext(), method, path) {
+				w.Header().Add("Allow", method)
+			}
+		}
+		kathttp.WriteErrResponse(w, kathttp.NewStatusErrResponse(http.StatusMethodNotAllowed, ""))
+	}
+}
 
-	// Write the error response as JSON
-	if err := json.NewEncoder(w).Encode(errResp); err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+// problemRecoverer is similar to middleware.Recoverer, but it reports panics as problem details
+func problemRecoverer(cfg *katapp.ServerConfig) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if rvr := recover(); rvr != nil {
+					if rvr == http.ErrAbortHandler {
+						// abort the response (see http.ErrAbortHandler)
+						panic(rvr)
+					}
+					middleware.PrintPrettyStack(rvr)
+					if r.Header.Get("Connection") != "Upgrade" {
+						kathttp.WriteErrResponse(kathttp.WithErrorReporting(w, r, cfg),
+							kathttp.NewStatusErrResponse(http.StatusInternalServerError, ""))
+					}
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
 	}
 }
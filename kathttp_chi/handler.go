@@ -2,6 +2,7 @@ package kathttp_chi
 
 import (
 	"github.com/go-chi/chi/v5"
+	"github.com/mobiletoly/gokatana/kathttp"
 	"github.com/mobiletoly/gokatana/kathttp_std"
 	"net/http"
 )
@@ -20,3 +21,11 @@ func WriteJSON(w http.ResponseWriter, statusCode int, data interface{}) error {
 func URLParam(r *http.Request, name string) string {
 	return chi.URLParam(r, name)
 }
+
+// BindRequest fills the struct from JSON body, path parameters, query parameters and headers of the request
+// and validates it (see kathttp.BindRequest)
+func BindRequest(r *http.Request, v any) error {
+	return kathttp.BindRequest(r, func(name string) string {
+		return chi.URLParam(r, name)
+	}, v)
+}
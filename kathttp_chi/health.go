@@ -0,0 +1,13 @@
+package kathttp_chi
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+)
+
+// RegisterHealthRoutes registers liveness (/healthz) and readiness (/readyz) probe endpoints
+func RegisterHealthRoutes(r chi.Router, registry *katapp.HealthRegistry) {
+	r.Method("GET", kathttp.LivenessPath, kathttp.LivenessHandler(registry))
+	r.Method("GET", kathttp.ReadinessPath, kathttp.ReadinessHandler(registry))
+}
@@ -8,8 +8,11 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
 	"github.com/mobiletoly/gokatana/kathttp_std"
+	"github.com/mobiletoly/gokatana/katmetrics"
 	"log/slog"
+	"net"
 	"net/http"
 )
 
@@ -20,11 +23,70 @@ func Start(
 	logger *slog.Logger,
 	setup func(r *chi.Mux) http.Handler,
 ) *http.Server {
+	// Create the HTTP server
+	listenAddr := fmt.Sprintf("%s:%d", cfg.Addr, cfg.Port)
+	katapp.Logger(ctx).InfoContext(ctx, fmt.Sprintf("Starting server on %s", listenAddr))
+
+	server := &http.Server{
+		Addr:    listenAddr,
+		Handler: newHandler(ctx, cfg, logger, setup),
+	}
+
+	// Start the server in a goroutine
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			katapp.Logger(ctx).InfoContext(ctx, "shutting down the server: ", "error", err)
+		}
+	}()
+
+	return server
+}
+
+// Serve listens on configured address and serves HTTP requests in background. Unlike Start, it returns error
+// if server cannot listen, while failure of serving is reported to the application (see katapp.FailApp).
+func Serve(
+	ctx context.Context,
+	cfg *katapp.ServerConfig,
+	logger *slog.Logger,
+	setup func(r *chi.Mux) http.Handler,
+) (*http.Server, error) {
+	listenAddr := fmt.Sprintf("%s:%d", cfg.Addr, cfg.Port)
+	ln, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", listenAddr, err)
+	}
+	server := &http.Server{
+		Addr:    listenAddr,
+		Handler: newHandler(ctx, cfg, logger, setup),
+	}
+
+	katapp.Logger(ctx).InfoContext(ctx, fmt.Sprintf("Starting server on %s", listenAddr))
+	go func() {
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			katapp.FailApp(ctx, fmt.Errorf("HTTP server has failed: %w", err))
+		}
+	}()
+
+	return server, nil
+}
+
+// newHandler creates handler of all server requests with routes set up by setup function
+func newHandler(
+	ctx context.Context,
+	cfg *katapp.ServerConfig,
+	logger *slog.Logger,
+	setup func(r *chi.Mux) http.Handler,
+) http.Handler {
 	inTest := katapp.RunningInTest(ctx)
 
 	r := chi.NewRouter()
 
-	r.Use(middleware.Recoverer)
+	if cfg.ErrorFormat == kathttp.ErrorFormatProblem {
+		r.Use(problemRecoverer(cfg))
+	} else {
+		r.Use(middleware.Recoverer)
+	}
+	r.Use(metricsMiddleware(kathttp.NewHTTPMetrics(katmetrics.Default)))
 	r.Use(middleware.RequestID)
 
 	// Add CORS middleware
@@ -46,29 +108,17 @@ func Start(
 		r.Use(kathttp_std.GzipDecompressMiddleware)
 	}
 
-	// Setup routes
-	handler := setup(r)
-
-	// Add request context middleware
-	r.Use(reqContextMiddleware(logger, inTest))
-
-	// Create the HTTP server
-	listenAddr := fmt.Sprintf("%s:%d", cfg.Addr, cfg.Port)
-	katapp.Logger(ctx).InfoContext(ctx, fmt.Sprintf("Starting server on %s", listenAddr))
-
-	server := &http.Server{
-		Addr:    listenAddr,
-		Handler: handler,
+	// Add request context and tracing middleware (chi requires all middleware to be added before routes)
+	r.Use(reqContextMiddleware(logger, inTest, cfg))
+	r.Use(tracingMiddleware)
+	r.Use(errorReportingMiddleware(cfg))
+	if cfg.ErrorFormat == kathttp.ErrorFormatProblem {
+		r.NotFound(notFoundHandler)
+		r.MethodNotAllowed(methodNotAllowedHandler(r))
 	}
 
-	// Start the server in a goroutine
-	go func() {
-		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			katapp.Logger(ctx).InfoContext(ctx, "shutting down the server: ", "error", err)
-		}
-	}()
-
-	return server
+	// Setup routes
+	return setup(r)
 }
 
 // Shutdown gracefully shuts down the server
@@ -0,0 +1,14 @@
+package kathttp_chi
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+)
+
+// RegisterLogLevelRoutes registers admin endpoint (/admin/loglevel) to view (GET) and change (PUT) log levels
+func RegisterLogLevelRoutes(r chi.Router, levels *katapp.LevelController) {
+	handler := kathttp.LogLevelHandler(levels)
+	r.Method("GET", kathttp.LogLevelPath, handler)
+	r.Method("PUT", kathttp.LogLevelPath, handler)
+}
@@ -0,0 +1,12 @@
+package kathttp_chi
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/mobiletoly/gokatana/kathttp"
+	"github.com/mobiletoly/gokatana/katmetrics"
+)
+
+// RegisterMetricsRoutes registers metrics endpoint (/metrics) serving metrics of the registry
+func RegisterMetricsRoutes(r chi.Router, registry *katmetrics.Registry) {
+	r.Method("GET", kathttp.MetricsPath, katmetrics.Handler(registry))
+}
@@ -0,0 +1,47 @@
+package kathttp_chi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/mobiletoly/gokatana/kathttp"
+)
+
+// metricsMiddleware records number and latency of served requests per chi route pattern
+func metricsMiddleware(metrics *kathttp.HTTPMetrics) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			defer func() {
+				route := routePattern(r)
+				if p := recover(); p != nil {
+					// panic is reported as internal server error by recoverer middleware
+					metrics.Observe(r.Method, route, http.StatusInternalServerError, time.Since(start))
+					panic(p)
+				}
+				metrics.Observe(r.Method, route, responseStatus(ww), time.Since(start))
+			}()
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+// routePattern returns pattern of the chi route matched by the request, it is complete only after
+// the request was routed
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
+
+// responseStatus returns the status code of the response (200 if nothing was written yet)
+func responseStatus(ww middleware.WrapResponseWriter) int {
+	if ww.Status() == 0 {
+		return http.StatusOK
+	}
+	return ww.Status()
+}
@@ -3,12 +3,19 @@ package kathttp_chi
 import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
 	"log/slog"
 	"net/http"
 )
 
 // reqContextMiddleware provides middleware for inserting required context values
-func reqContextMiddleware(logger *slog.Logger, runInTest bool) func(next http.Handler) http.Handler {
+func reqContextMiddleware(
+	logger *slog.Logger,
+	runInTest bool,
+	cfg *katapp.ServerConfig,
+) func(next http.Handler) http.Handler {
+	debugLog := kathttp.NewDebugLogFilter(cfg.DebugLogNetworks)
+	baggage := kathttp.NewBaggageFilter(cfg.BaggageKeys)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
@@ -27,6 +34,19 @@ func reqContextMiddleware(logger *slog.Logger, runInTest bool) func(next http.Ha
 			// Add logger with request ID to context
 			ctx = katapp.ContextWithRequestLogger(ctx, logger, requestID)
 
+			// Add allowed correlation baggage of the caller
+			ctx = baggage.ContextWithRequestBaggage(ctx, r)
+
+			// Limit size of request body decoded by BindRequest
+			if cfg.MaxBodySize > 0 {
+				ctx = kathttp.ContextWithMaxBodySize(ctx, cfg.MaxBodySize)
+			}
+
+			// Enable debug logging of the request if trusted caller asked for it
+			if debugLog.DebugLogRequested(r) {
+				ctx = katapp.ContextWithDebugLogging(ctx)
+			}
+
 			// Create a new request with the updated context
 			r = r.WithContext(ctx)
 
@@ -0,0 +1,26 @@
+package kathttp_chi
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/mobiletoly/gokatana/kathttp"
+)
+
+// tracingMiddleware starts server span of the request (continuing trace of the caller) and enriches
+// request logger with trace and span IDs
+func tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := kathttp.StartServerSpan(r.Context(), r)
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		r = r.WithContext(ctx)
+		defer func() {
+			if p := recover(); p != nil {
+				kathttp.EndServerSpan(span, r.Method, routePattern(r), http.StatusInternalServerError)
+				panic(p)
+			}
+			kathttp.EndServerSpan(span, r.Method, routePattern(r), responseStatus(ww))
+		}()
+		next.ServeHTTP(ww, r)
+	})
+}
@@ -0,0 +1,13 @@
+package kathttp_echo
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mobiletoly/gokatana/kathttp"
+)
+
+// BindRequest fills the struct from JSON body, path parameters, query parameters and headers of the request
+// and validates it (see kathttp.BindRequest). Errors are reported as ErrInvalidInput application errors,
+// so they are mapped to 400 responses with violations.
+func BindRequest(c echo.Context, v any) error {
+	return kathttp.BindRequest(c.Request(), c.Param, v)
+}
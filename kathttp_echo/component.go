@@ -0,0 +1,26 @@
+package kathttp_echo
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mobiletoly/gokatana/katapp"
+)
+
+// ServerComponent returns application component (see katapp.App) that starts HTTP server (see Serve)
+// when application is started and gracefully shuts it down when application is stopped
+func ServerComponent(
+	cfg *katapp.ServerConfig,
+	logger *slog.Logger,
+	setup func(e *echo.Echo),
+) katapp.Component {
+	var e *echo.Echo
+	return katapp.NewComponent("http", func(ctx context.Context) error {
+		var err error
+		e, err = Serve(ctx, cfg, logger, setup)
+		return err
+	}, func(ctx context.Context) error {
+		return e.Shutdown(ctx)
+	})
+}
@@ -0,0 +1,28 @@
+package kathttp_echo
+
+import (
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kattest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerComponent_FailsAppIfPortIsInUse(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	cfg := &katapp.ServerConfig{Addr: "127.0.0.1", Port: ln.Addr().(*net.TCPAddr).Port}
+	app := katapp.NewApp(time.Second)
+	app.Add(ServerComponent(cfg, slog.New(slog.DiscardHandler), func(e *echo.Echo) {}))
+
+	err = app.Run(kattest.AppTestContext())
+	assert.ErrorContains(t, err, "failed to start component http")
+	assert.ErrorContains(t, err, "address already in use")
+}
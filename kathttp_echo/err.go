@@ -3,6 +3,7 @@ package kathttp_echo
 import (
 	"errors"
 	"github.com/labstack/echo/v4"
+	"github.com/mobiletoly/gokatana/katapp"
 	"github.com/mobiletoly/gokatana/kathttp"
 	"net/http"
 )
@@ -40,3 +41,54 @@ func ReportUnauthorized(err error) *echo.HTTPError {
 func ReportForbidden(err error) *echo.HTTPError {
 	return echo.NewHTTPError(http.StatusForbidden, kathttp.NewForbiddenErrResponse(err))
 }
+
+// errorHandler is echo.HTTPErrorHandler that writes errors in error format and with error policy of the server.
+// Error responses of ReportHTTPError and GuessHTTPErrorMiddleware are written as is. Other errors are handled by
+// echo.DefaultHTTPErrorHandler in JSON format, in problem format errors of the router (404 and 405) are converted
+// by their status and remaining errors (including recovered panics) are guessed by kathttp.GuessHTTPError.
+func errorHandler(e *echo.Echo, cfg *katapp.ServerConfig) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+		var errResp *kathttp.ErrResponse
+		var he *echo.HTTPError
+		isHTTPErr := errors.As(err, &he)
+		if isHTTPErr {
+			errResp, _ = he.Message.(*kathttp.ErrResponse)
+		}
+		if errResp == nil {
+			if cfg.ErrorFormat != kathttp.ErrorFormatProblem {
+				e.DefaultHTTPErrorHandler(err, c)
+				return
+			}
+			errResp = problemErrResponse(err, he, isHTTPErr)
+		}
+		w := kathttp.WithErrorReporting(c.Response(), c.Request(), cfg)
+		kathttp.WriteErrResponse(w, errResp)
+	}
+}
+
+// problemErrResponse converts errors of the router (404 and 405) by their status,
+// other errors (including recovered panics) are guessed by kathttp.GuessHTTPError
+func problemErrResponse(err error, he *echo.HTTPError, isHTTPErr bool) *kathttp.ErrResponse {
+	if !isHTTPErr {
+		return kathttp.GuessHTTPError(err)
+	}
+	if msg, ok := he.Message.(string); ok && msg != http.StatusText(he.Code) {
+		return kathttp.NewStatusErrResponse(he.Code, msg)
+	}
+	return kathttp.NewStatusErrResponse(he.Code, "")
+}
+
+// errorReportingMiddleware makes error responses of wrapped http.Handler endpoints (e.g. health and log level
+// endpoints) use the error format and error policy of the server
+func errorReportingMiddleware(cfg *katapp.ServerConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			res := c.Response()
+			res.Writer = kathttp.WithErrorReporting(res.Writer, c.Request(), cfg)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package kathttp_echo
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mobiletoly/gokatana/katapp"
+)
+
+// RequireFlag returns middleware that serves routes only if feature flag is enabled for the request
+// context (requests are rejected with 404 Not Found otherwise)
+func RequireFlag(flags *katapp.Flags, name string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !flags.Enabled(c.Request().Context(), name) {
+				return ReportHTTPError(katapp.NewErr(katapp.ErrNotFound, "not found"))
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package kathttp_echo
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+)
+
+// RegisterHealthRoutes registers liveness (/healthz) and readiness (/readyz) probe endpoints
+func RegisterHealthRoutes(e *echo.Echo, registry *katapp.HealthRegistry) {
+	e.GET(kathttp.LivenessPath, echo.WrapHandler(kathttp.LivenessHandler(registry)))
+	e.GET(kathttp.ReadinessPath, echo.WrapHandler(kathttp.ReadinessHandler(registry)))
+}
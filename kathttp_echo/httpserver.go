@@ -7,7 +7,10 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+	"github.com/mobiletoly/gokatana/katmetrics"
 	"log/slog"
+	"net"
 	"net/http"
 )
 
@@ -16,10 +19,56 @@ func Start(
 	cfg *katapp.ServerConfig,
 	logger *slog.Logger,
 	setup func(e *echo.Echo),
+) *echo.Echo {
+	e := newEcho(ctx, cfg, logger, setup)
+	listenAddr := fmt.Sprintf("%s:%d", cfg.Addr, cfg.Port)
+	katapp.Logger(ctx).InfoContext(ctx, fmt.Sprintf("Starting server on %s", listenAddr))
+	go func() {
+		if err := e.Start(listenAddr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			katapp.Logger(ctx).InfoContext(ctx, "shutting down the server: ", "error", err)
+		}
+	}()
+
+	return e
+}
+
+// Serve listens on configured address and serves HTTP requests in background. Unlike Start, it returns error
+// if server cannot listen, while failure of serving is reported to the application (see katapp.FailApp).
+func Serve(
+	ctx context.Context,
+	cfg *katapp.ServerConfig,
+	logger *slog.Logger,
+	setup func(e *echo.Echo),
+) (*echo.Echo, error) {
+	listenAddr := fmt.Sprintf("%s:%d", cfg.Addr, cfg.Port)
+	ln, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", listenAddr, err)
+	}
+	e := newEcho(ctx, cfg, logger, setup)
+	e.Listener = ln
+
+	katapp.Logger(ctx).InfoContext(ctx, fmt.Sprintf("Starting server on %s", listenAddr))
+	go func() {
+		if err := e.Start(listenAddr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			katapp.FailApp(ctx, fmt.Errorf("HTTP server has failed: %w", err))
+		}
+	}()
+
+	return e, nil
+}
+
+// newEcho creates echo instance with middleware of the server and routes set up by setup function
+func newEcho(
+	ctx context.Context,
+	cfg *katapp.ServerConfig,
+	logger *slog.Logger,
+	setup func(e *echo.Echo),
 ) *echo.Echo {
 	inTest := katapp.RunningInTest(ctx)
 
 	e := echo.New()
+	e.HTTPErrorHandler = errorHandler(e, cfg)
 	e.Use(middleware.RecoverWithConfig(middleware.RecoverConfig{
 		DisableStackAll:   false,
 		DisablePrintStack: false,
@@ -28,8 +77,10 @@ func Start(
 			return err
 		},
 	}))
+	e.Use(metricsMiddleware(kathttp.NewHTTPMetrics(katmetrics.Default)))
 	e.Use(middleware.CORS())
 	e.Use(middleware.RequestID())
+	e.Use(errorReportingMiddleware(cfg))
 	if cfg.ResponseCompression == "gzip" {
 		e.Use(middleware.GzipWithConfig(middleware.GzipConfig{}))
 	}
@@ -38,15 +89,7 @@ func Start(
 	}
 
 	setup(e)
-	e.Use(reqContextMiddleware(logger, inTest))
-
-	listenAddr := fmt.Sprintf("%s:%d", cfg.Addr, cfg.Port)
-	katapp.Logger(ctx).InfoContext(ctx, fmt.Sprintf("Starting server on %s", listenAddr))
-	go func() {
-		if err := e.Start(listenAddr); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			katapp.Logger(ctx).InfoContext(ctx, "shutting down the server: ", "error", err)
-		}
-	}()
-
+	e.Use(reqContextMiddleware(logger, inTest, cfg))
+	e.Use(tracingMiddleware)
 	return e
 }
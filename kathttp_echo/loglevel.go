@@ -0,0 +1,14 @@
+package kathttp_echo
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+)
+
+// RegisterLogLevelRoutes registers admin endpoint (/admin/loglevel) to view (GET) and change (PUT) log levels
+func RegisterLogLevelRoutes(e *echo.Echo, levels *katapp.LevelController) {
+	handler := echo.WrapHandler(kathttp.LogLevelHandler(levels))
+	e.GET(kathttp.LogLevelPath, handler)
+	e.PUT(kathttp.LogLevelPath, handler)
+}
@@ -0,0 +1,12 @@
+package kathttp_echo
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mobiletoly/gokatana/kathttp"
+	"github.com/mobiletoly/gokatana/katmetrics"
+)
+
+// RegisterMetricsRoutes registers metrics endpoint (/metrics) serving metrics of the registry
+func RegisterMetricsRoutes(e *echo.Echo, registry *katmetrics.Registry) {
+	e.GET(kathttp.MetricsPath, echo.WrapHandler(katmetrics.Handler(registry)))
+}
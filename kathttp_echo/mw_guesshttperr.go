@@ -1,20 +1,29 @@
 package kathttp_echo
 
 import (
+	"errors"
+
 	"github.com/labstack/echo/v4"
 	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
 )
 
-// GuessHTTPErrorMiddleware provides middleware for guessing HTTP errors
+// GuessHTTPErrorMiddleware provides middleware for guessing HTTP errors. Internal errors are logged
+// with their call stack and fingerprint, the fingerprint is also reported in the response.
 func GuessHTTPErrorMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		err := next(c)
 		if err != nil {
 			ctx := c.Request().Context()
-			err = ReportHTTPError(err)
-			katapp.Logger(ctx).Error("HTTP error reported", "error", err,
-				"URL", c.Request().URL, "method", c.Request().Method)
-			return err
+			var he *echo.HTTPError
+			if errors.As(err, &he) {
+				katapp.Logger(ctx).Error("HTTP error reported", "error", he,
+					"URL", c.Request().URL, "method", c.Request().Method)
+				return he
+			}
+			errResp := kathttp.ApplyErrPolicy(c.Response(), kathttp.GuessHTTPError(err))
+			kathttp.LogHTTPError(ctx, c.Request(), err, errResp)
+			return echo.NewHTTPError(errResp.HTTPStatusCode, errResp)
 		}
 		return nil
 	}
@@ -0,0 +1,42 @@
+package kathttp_echo
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mobiletoly/gokatana/kathttp"
+)
+
+// metricsMiddleware records number and latency of served requests per echo route path
+func metricsMiddleware(metrics *kathttp.HTTPMetrics) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			defer func() {
+				if p := recover(); p != nil {
+					// panic is reported as internal server error by recover middleware
+					metrics.Observe(c.Request().Method, c.Path(), http.StatusInternalServerError, time.Since(start))
+					panic(p)
+				}
+			}()
+			err := next(c)
+			metrics.Observe(c.Request().Method, c.Path(), responseStatus(c, err), time.Since(start))
+			return err
+		}
+	}
+}
+
+// responseStatus returns status of the response, errors are not written yet and their status
+// is guessed the same way as echo error handler does
+func responseStatus(c echo.Context, err error) int {
+	if err == nil || c.Response().Committed {
+		return c.Response().Status
+	}
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return he.Code
+	}
+	return http.StatusInternalServerError
+}
@@ -3,11 +3,18 @@ package kathttp_echo
 import (
 	"github.com/labstack/echo/v4"
 	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
 	"log/slog"
 )
 
 // reqContextMiddleware provides middleware for inserting required context values
-func reqContextMiddleware(logger *slog.Logger, runInTest bool) func(next echo.HandlerFunc) echo.HandlerFunc {
+func reqContextMiddleware(
+	logger *slog.Logger,
+	runInTest bool,
+	cfg *katapp.ServerConfig,
+) func(next echo.HandlerFunc) echo.HandlerFunc {
+	debugLog := kathttp.NewDebugLogFilter(cfg.DebugLogNetworks)
+	baggage := kathttp.NewBaggageFilter(cfg.BaggageKeys)
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		fn := func(c echo.Context) error {
 			req := c.Request()
@@ -21,6 +28,13 @@ func reqContextMiddleware(logger *slog.Logger, runInTest bool) func(next echo.Ha
 				requestID = res.Header().Get(echo.HeaderXRequestID)
 			}
 			ctx = katapp.ContextWithRequestLogger(ctx, logger, requestID)
+			ctx = baggage.ContextWithRequestBaggage(ctx, req)
+			if cfg.MaxBodySize > 0 {
+				ctx = kathttp.ContextWithMaxBodySize(ctx, cfg.MaxBodySize)
+			}
+			if debugLog.DebugLogRequested(req) {
+				ctx = katapp.ContextWithDebugLogging(ctx)
+			}
 			c.SetRequest(req.WithContext(ctx))
 			return next(c)
 		}
@@ -0,0 +1,27 @@
+package kathttp_echo
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mobiletoly/gokatana/kathttp"
+)
+
+// tracingMiddleware starts server span of the request (continuing trace of the caller) and enriches
+// request logger with trace and span IDs
+func tracingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		ctx, span := kathttp.StartServerSpan(req.Context(), req)
+		c.SetRequest(req.WithContext(ctx))
+		defer func() {
+			if p := recover(); p != nil {
+				kathttp.EndServerSpan(span, req.Method, c.Path(), http.StatusInternalServerError)
+				panic(p)
+			}
+		}()
+		err := next(c)
+		kathttp.EndServerSpan(span, req.Method, c.Path(), responseStatus(c, err))
+		return err
+	}
+}
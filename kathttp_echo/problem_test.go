@@ -0,0 +1,99 @@
+package kathttp_echo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newProblemTestHandler() http.Handler {
+	cfg := &katapp.ServerConfig{ErrorFormat: kathttp.ErrorFormatProblem}
+	health := katapp.NewHealthRegistry(0)
+	health.Register(katapp.HealthCheck{
+		Name:     "db",
+		Check:    func(ctx context.Context) error { return errors.New("connection refused") },
+		Critical: true,
+	})
+	return newEcho(context.Background(), cfg, slog.New(slog.DiscardHandler), func(e *echo.Echo) {
+		e.GET("/items/:id", func(c echo.Context) error {
+			return ReportHTTPError(katapp.NewErr(katapp.ErrNotFound, "item not found"))
+		})
+		e.GET("/panic", func(c echo.Context) error {
+			panic("boom")
+		})
+		RegisterHealthRoutes(e, health)
+	})
+}
+
+func serveProblem(t *testing.T, handler http.Handler, method, path string) (*httptest.ResponseRecorder, kathttp.Problem) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	assert.Equal(t, kathttp.ProblemContentType, rec.Header().Get("Content-Type"))
+	var problem kathttp.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, rec.Code, problem.Status)
+	assert.Equal(t, http.StatusText(rec.Code), problem.Title)
+	return rec, problem
+}
+
+func TestProblemFormat(t *testing.T) {
+	handler := newProblemTestHandler()
+
+	t.Run("app error", func(t *testing.T) {
+		rec, problem := serveProblem(t, handler, "GET", "/items/1")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "item not found", problem.Detail)
+		assert.Equal(t, "not_found", problem.Code)
+		assert.NotEmpty(t, problem.Instance)
+	})
+	t.Run("route not found", func(t *testing.T) {
+		rec, problem := serveProblem(t, handler, "GET", "/missing")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Empty(t, problem.Detail)
+	})
+	t.Run("method not allowed", func(t *testing.T) {
+		rec, _ := serveProblem(t, handler, "POST", "/items/1")
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		assert.Contains(t, rec.Header().Get("Allow"), "GET")
+	})
+	t.Run("recovered panic", func(t *testing.T) {
+		rec, _ := serveProblem(t, handler, "GET", "/panic")
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+	t.Run("unhealthy", func(t *testing.T) {
+		rec, problem := serveProblem(t, handler, "GET", kathttp.ReadinessPath)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "application is not healthy", problem.Detail)
+		assert.Contains(t, problem.Details["checks"], "db")
+	})
+}
+
+func TestErrPolicy_HidesInternalErrors(t *testing.T) {
+	cfg := &katapp.ServerConfig{InternalErrors: kathttp.InternalErrorsHide}
+	e := newEcho(context.Background(), cfg, slog.New(slog.DiscardHandler), func(e *echo.Echo) {
+		e.GET("/fail", func(c echo.Context) error {
+			return ReportHTTPError(errors.New("connection refused"))
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/fail", nil))
+	var errResp kathttp.ErrResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errResp))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, kathttp.InternalErrorMessage, errResp.ErrorText)
+	assert.NotEmpty(t, errResp.ErrorRef)
+}
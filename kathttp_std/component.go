@@ -0,0 +1,26 @@
+package kathttp_std
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/mobiletoly/gokatana/katapp"
+)
+
+// ServerComponent returns application component (see katapp.App) that starts HTTP server (see Serve)
+// when application is started and gracefully shuts it down when application is stopped
+func ServerComponent(
+	cfg *katapp.ServerConfig,
+	logger *slog.Logger,
+	setup func(mux *http.ServeMux) http.Handler,
+) katapp.Component {
+	var server *http.Server
+	return katapp.NewComponent("http", func(ctx context.Context) error {
+		var err error
+		server, err = Serve(ctx, cfg, logger, setup)
+		return err
+	}, func(ctx context.Context) error {
+		return server.Shutdown(ctx)
+	})
+}
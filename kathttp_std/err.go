@@ -1,20 +1,88 @@
 package kathttp_std
 
 import (
-	"encoding/json"
-	"github.com/mobiletoly/gokatana/kathttp"
+	"bufio"
+	"net"
 	"net/http"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
 )
 
 // ReportHTTPError writes an error response to the HTTP response writer
+// (in error format of the server, see katapp.ServerConfig.ErrorFormat)
 func ReportHTTPError(w http.ResponseWriter, err error) {
-	errResp := kathttp.GuessHTTPError(err)
-	// Set content type and status code
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(errResp.HTTPStatusCode)
-
-	// Write the error response as JSON
-	if err := json.NewEncoder(w).Encode(errResp); err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	kathttp.WriteHTTPError(w, err)
+}
+
+// LogAndReportHTTPError logs error (internal errors are logged with their call stack and fingerprint)
+// and writes an error response to the HTTP response writer
+func LogAndReportHTTPError(w http.ResponseWriter, r *http.Request, err error) {
+	kathttp.LogAndWriteHTTPError(w, r, err)
+}
+
+// errorReportingMiddleware makes error responses of handlers use the error format and error policy of the server.
+// In problem format errors reported by the mux itself (404 and 405) are rewritten as problem details.
+func errorReportingMiddleware(mux *http.ServeMux, cfg *katapp.ServerConfig) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w = kathttp.WithErrorReporting(w, r, cfg)
+			if cfg.ErrorFormat == kathttp.ErrorFormatProblem {
+				w = &muxErrorWriter{ResponseWriter: w, mux: mux, r: r}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// muxErrorWriter replaces 404 and 405 responses of the mux with error responses. Route of the request
+// is resolved only when such response is written (handlers can report 404 and 405 as well).
+type muxErrorWriter struct {
+	http.ResponseWriter
+	mux       *http.ServeMux
+	r         *http.Request
+	rewritten bool
+}
+
+func (w *muxErrorWriter) WriteHeader(code int) {
+	if (code == http.StatusNotFound || code == http.StatusMethodNotAllowed) && !w.matchesRoute() {
+		// mux has already set "Allow" header of 405 response
+		w.rewritten = true
+		kathttp.WriteErrResponse(w.ResponseWriter, kathttp.NewStatusErrResponse(code, ""))
+		return
 	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *muxErrorWriter) Write(b []byte) (int, error) {
+	if w.rewritten {
+		// body of the mux response is discarded
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// matchesRoute checks if request matches a route of the mux. Pattern of the route is set by the mux on
+// the request it serves, but middleware between the mux and this writer could have replaced the request.
+func (w *muxErrorWriter) matchesRoute() bool {
+	if w.r.Pattern != "" {
+		return true
+	}
+	_, pattern := w.mux.Handler(w.r)
+	return pattern != ""
+}
+
+// Unwrap allows http.ResponseController to access the underlying ResponseWriter
+func (w *muxErrorWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush forwards to the underlying ResponseWriter, so handlers can stream responses (e.g. server-sent events)
+func (w *muxErrorWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack forwards to the underlying ResponseWriter, so handlers can take over the connection (e.g. websockets)
+func (w *muxErrorWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
 }
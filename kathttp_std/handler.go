@@ -3,6 +3,7 @@ package kathttp_std
 import (
 	"encoding/json"
 	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
 	"io"
 	"net/http"
 	"strings"
@@ -13,7 +14,7 @@ func Bind(r *http.Request, v interface{}) error {
 	// Read the request body
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		return katapp.NewErr(katapp.ErrInternal, "failed to read request body")
+		return katapp.Wrap(katapp.ErrInternal, err, "failed to read request body")
 	}
 	defer r.Body.Close()
 
@@ -21,10 +22,16 @@ func Bind(r *http.Request, v interface{}) error {
 	contentType := r.Header.Get("Content-Type")
 	if strings.Contains(contentType, "application/json") {
 		if err := json.Unmarshal(body, v); err != nil {
-			return katapp.NewErr(katapp.ErrInvalidInput, "failed to parse JSON request body")
+			return katapp.Wrap(katapp.ErrInvalidInput, err, "failed to parse JSON request body")
 		}
 		return nil
 	}
 
 	return katapp.NewErr(katapp.ErrInvalidInput, "unsupported content type")
 }
+
+// BindRequest fills the struct from JSON body, path parameters, query parameters and headers of the request
+// and validates it (see kathttp.BindRequest)
+func BindRequest(r *http.Request, v any) error {
+	return kathttp.BindRequest(r, r.PathValue, v)
+}
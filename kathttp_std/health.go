@@ -0,0 +1,14 @@
+package kathttp_std
+
+import (
+	"net/http"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+)
+
+// RegisterHealthRoutes registers liveness (/healthz) and readiness (/readyz) probe endpoints
+func RegisterHealthRoutes(mux *http.ServeMux, registry *katapp.HealthRegistry) {
+	mux.Handle("GET "+kathttp.LivenessPath, kathttp.LivenessHandler(registry))
+	mux.Handle("GET "+kathttp.ReadinessPath, kathttp.ReadinessHandler(registry))
+}
@@ -5,7 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+	"github.com/mobiletoly/gokatana/katmetrics"
 	"log/slog"
+	"net"
 	"net/http"
 	"time"
 )
@@ -17,14 +20,72 @@ func Start(
 	logger *slog.Logger,
 	setup func(mux *http.ServeMux) http.Handler,
 ) *http.Server {
+	// Create the HTTP server
+	listenAddr := fmt.Sprintf("%s:%d", cfg.Addr, cfg.Port)
+	server := &http.Server{
+		Addr:    listenAddr,
+		Handler: newHandler(ctx, cfg, logger, setup),
+	}
+
+	// Start the server in a goroutine
+	katapp.Logger(ctx).InfoContext(ctx, fmt.Sprintf("Starting server on %s", listenAddr))
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			katapp.Logger(ctx).InfoContext(ctx, "shutting down the server: ", "error", err)
+		}
+	}()
+
+	return server
+}
+
+// Serve listens on configured address and serves HTTP requests in background. Unlike Start, it returns error
+// if server cannot listen, while failure of serving is reported to the application (see katapp.FailApp).
+func Serve(
+	ctx context.Context,
+	cfg *katapp.ServerConfig,
+	logger *slog.Logger,
+	setup func(mux *http.ServeMux) http.Handler,
+) (*http.Server, error) {
+	listenAddr := fmt.Sprintf("%s:%d", cfg.Addr, cfg.Port)
+	ln, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", listenAddr, err)
+	}
+	server := &http.Server{
+		Addr:    listenAddr,
+		Handler: newHandler(ctx, cfg, logger, setup),
+	}
+
+	katapp.Logger(ctx).InfoContext(ctx, fmt.Sprintf("Starting server on %s", listenAddr))
+	go func() {
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			katapp.FailApp(ctx, fmt.Errorf("HTTP server has failed: %w", err))
+		}
+	}()
+
+	return server, nil
+}
+
+// newHandler creates handler of all server requests with routes set up by setup function
+func newHandler(
+	ctx context.Context,
+	cfg *katapp.ServerConfig,
+	logger *slog.Logger,
+	setup func(mux *http.ServeMux) http.Handler,
+) http.Handler {
 	inTest := katapp.RunningInTest(ctx)
 	router := http.NewServeMux()
 
 	// Setup routes
 	handler := setup(router)
+	handler = errorReportingMiddleware(router, cfg)(handler)
+
+	// Record metrics and traces of served requests labeled with route patterns of the mux
+	handler = metricsMiddleware(kathttp.NewHTTPMetrics(katmetrics.Default), router)(handler)
+	handler = tracingMiddleware(router)(handler)
 
 	// Add middleware in reverse order (last added is executed first)
-	handler = reqContextMiddleware(logger, inTest)(handler)
+	handler = reqContextMiddleware(logger, inTest, cfg)(handler)
 
 	if cfg.RequestDecompression == "request-gzip" {
 		handler = GzipDecompressMiddleware(handler)
@@ -35,24 +96,7 @@ func Start(
 
 	handler = requestIDMiddleware(handler)
 	handler = corsMiddleware(handler)
-	handler = recoveryMiddleware(handler)
-
-	// Create the HTTP server
-	listenAddr := fmt.Sprintf("%s:%d", cfg.Addr, cfg.Port)
-	server := &http.Server{
-		Addr:    listenAddr,
-		Handler: handler,
-	}
-
-	// Start the server in a goroutine
-	katapp.Logger(ctx).InfoContext(ctx, fmt.Sprintf("Starting server on %s", listenAddr))
-	go func() {
-		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			katapp.Logger(ctx).InfoContext(ctx, "shutting down the server: ", "error", err)
-		}
-	}()
-
-	return server
+	return recoveryMiddleware(logger, cfg)(handler)
 }
 
 // WaitForInterruptSignal waits for interrupt signal to gracefully shut down the server with a timeout.
@@ -62,19 +106,27 @@ func WaitForInterruptSignal(ctx context.Context, server *http.Server, timeout ti
 	})
 }
 
-// recoveryMiddleware provides panic recovery similar to Echo's middleware.Recover()
-func recoveryMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				fmt.Fprintf(w, "Internal Server Error")
-				// Log the panic
-				katapp.Logger(r.Context()).ErrorContext(r.Context(), "panic recovered", "error", err)
-			}
-		}()
-		next.ServeHTTP(w, r)
-	})
+// recoveryMiddleware provides panic recovery similar to Echo's middleware.Recover(). It is the outermost
+// middleware, so panics are logged with the server logger (request logger is not set up yet).
+func recoveryMiddleware(logger *slog.Logger, cfg *katapp.ServerConfig) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if err := recover(); err != nil {
+					if cfg.ErrorFormat == kathttp.ErrorFormatProblem {
+						kathttp.WriteErrResponse(kathttp.WithErrorReporting(w, r, cfg),
+							kathttp.NewStatusErrResponse(http.StatusInternalServerError, ""))
+					} else {
+						w.WriteHeader(http.StatusInternalServerError)
+						fmt.Fprintf(w, "Internal Server Error")
+					}
+					// Log the panic
+					logger.ErrorContext(r.Context(), "panic recovered", "error", err)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
 // corsMiddleware provides CORS support similar to Echo's middleware.CORS()
@@ -0,0 +1,15 @@
+package kathttp_std
+
+import (
+	"net/http"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+)
+
+// RegisterLogLevelRoutes registers admin endpoint (/admin/loglevel) to view (GET) and change (PUT) log levels
+func RegisterLogLevelRoutes(mux *http.ServeMux, levels *katapp.LevelController) {
+	handler := kathttp.LogLevelHandler(levels)
+	mux.Handle("GET "+kathttp.LogLevelPath, handler)
+	mux.Handle("PUT "+kathttp.LogLevelPath, handler)
+}
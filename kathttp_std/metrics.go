@@ -0,0 +1,13 @@
+package kathttp_std
+
+import (
+	"net/http"
+
+	"github.com/mobiletoly/gokatana/kathttp"
+	"github.com/mobiletoly/gokatana/katmetrics"
+)
+
+// RegisterMetricsRoutes registers metrics endpoint (/metrics) serving metrics of the registry
+func RegisterMetricsRoutes(mux *http.ServeMux, registry *katmetrics.Registry) {
+	mux.Handle("GET "+kathttp.MetricsPath, katmetrics.Handler(registry))
+}
@@ -0,0 +1,96 @@
+package kathttp_std
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mobiletoly/gokatana/kathttp"
+)
+
+// statusRecorder captures the status code of the response
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	if w.statusCode == 0 {
+		w.statusCode = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// status returns the status code of the response (200 if nothing was written yet)
+func (w *statusRecorder) status() int {
+	if w.statusCode == 0 {
+		return http.StatusOK
+	}
+	return w.statusCode
+}
+
+// Unwrap allows http.ResponseController to access the underlying ResponseWriter
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush forwards to the underlying ResponseWriter, so handlers can stream responses (e.g. server-sent events)
+func (w *statusRecorder) Flush() {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack forwards to the underlying ResponseWriter, so handlers can take over the connection (e.g. websockets).
+// It returns http.ErrNotSupported if the underlying ResponseWriter cannot be hijacked.
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.statusCode == 0 {
+		w.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// metricsMiddleware records number and latency of served requests labeled with route pattern of the mux
+// (see routePattern)
+func metricsMiddleware(metrics *kathttp.HTTPMetrics, mux *http.ServeMux) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			defer func() {
+				if p := recover(); p != nil {
+					// panic is reported as internal server error by recovery middleware
+					metrics.Observe(r.Method, routePattern(mux, r), http.StatusInternalServerError, time.Since(start))
+					panic(p)
+				}
+				metrics.Observe(r.Method, routePattern(mux, r), rec.status(), time.Since(start))
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+// routePattern returns pattern of the route matched by the mux without method (e.g. "/users/{id}"). Pattern is
+// set by the mux on the request it serves, but middleware between the mux and the caller could have replaced
+// the request (e.g. with r.WithContext), then the route is matched again.
+func routePattern(mux *http.ServeMux, r *http.Request) string {
+	pattern := r.Pattern
+	if pattern == "" {
+		_, pattern = mux.Handler(r)
+	}
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return strings.TrimSpace(path)
+	}
+	return pattern
+}
@@ -0,0 +1,82 @@
+package kathttp_std
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/kathttp"
+	"github.com/mobiletoly/gokatana/katmetrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsMiddleware_RoutePatternOfReplacedRequest(t *testing.T) {
+	reg := katmetrics.NewRegistry()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	// middleware of the application replaces request, so pattern set by the mux is not visible outside
+	type testContextKey struct{}
+	cloning := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), testContextKey{}, "value")))
+	})
+	handler := metricsMiddleware(kathttp.NewHTTPMetrics(reg), mux)(cloning)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+
+	var sb strings.Builder
+	require.NoError(t, reg.WriteText(&sb))
+	assert.Contains(t, sb.String(), `route="/users/{id}",status="204"`)
+	assert.NotContains(t, sb.String(), "/users/42")
+	assert.Contains(t, sb.String(), `status="404"`)
+}
+
+func TestMetricsMiddleware_PreservesFlusher(t *testing.T) {
+	handler := metricsMiddleware(kathttp.NewHTTPMetrics(katmetrics.NewRegistry()), http.NewServeMux())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			flusher, ok := w.(http.Flusher)
+			require.True(t, ok)
+			_, _ = w.Write([]byte("event"))
+			flusher.Flush()
+		}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/events", nil))
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, "event", rec.Body.String())
+}
+
+func TestMetricsMiddleware_PreservesHijacker(t *testing.T) {
+	mux := http.NewServeMux()
+	handler := tracingMiddleware(mux)(metricsMiddleware(kathttp.NewHTTPMetrics(katmetrics.NewRegistry()), mux)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// handler runs in a goroutine of the server, so assertions must not stop the test
+			hijacker, ok := w.(http.Hijacker)
+			if !assert.True(t, ok) {
+				return
+			}
+			conn, rw, err := hijacker.Hijack()
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.Close()
+			_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+			_ = rw.Flush()
+		})))
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(bufio.NewReader(resp.Body))
+	require.NoError(t, err)
+	assert.Equal(t, "hijacked", string(body))
+}
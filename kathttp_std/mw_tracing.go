@@ -0,0 +1,27 @@
+package kathttp_std
+
+import (
+	"net/http"
+
+	"github.com/mobiletoly/gokatana/kathttp"
+)
+
+// tracingMiddleware starts server span of the request (continuing trace of the caller) and enriches
+// request logger with trace and span IDs (span is named after route pattern of the mux, see routePattern)
+func tracingMiddleware(mux *http.ServeMux) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := kathttp.StartServerSpan(r.Context(), r)
+			rec := &statusRecorder{ResponseWriter: w}
+			r = r.WithContext(ctx)
+			defer func() {
+				if p := recover(); p != nil {
+					kathttp.EndServerSpan(span, r.Method, routePattern(mux, r), http.StatusInternalServerError)
+					panic(p)
+				}
+				kathttp.EndServerSpan(span, r.Method, routePattern(mux, r), rec.status())
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
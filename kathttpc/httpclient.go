@@ -9,11 +9,15 @@ import (
 	"log/slog"
 	"maps"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kathttp"
+	"github.com/mobiletoly/gokatana/katmetrics"
+	"github.com/mobiletoly/gokatana/kattrace"
 )
 
 type UnexpectedStatusCodeError struct {
@@ -73,18 +77,31 @@ type JsonResponse[TBody any, TErr any] struct {
 func DoBodyRequest(
 	ctx context.Context, client *http.Client, method string, reqURL string, req BodyRequest,
 ) (*BodyResponse, error) {
-	// Create a grouped logger
-	logger := katapp.Logger(ctx).WithGroup("kathttpc.DoBodyRequest").With(
+	// Create a grouped logger (URLs, headers and errors can contain credentials)
+	logger := katapp.Logger(ctx).Redacted().WithGroup("kathttpc.DoBodyRequest").With(
 		"reqURL", reqURL,
 		"method", method,
 	)
 
+	// Client span is a parent of spans of the callee (its context is propagated with correlation headers)
+	ctx, span := kattrace.Start(ctx, method, kattrace.SpanKindClient,
+		slog.String("http.request.method", method))
+	defer span.End()
+
 	httpReq, err := http.NewRequestWithContext(ctx, method, reqURL, req.Body)
 	if err != nil {
+		span.RecordError(err)
 		logger.ErrorContext(ctx, "error creating request", "error", err)
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
+	span.SetAttrs(slog.String("server.address", httpReq.URL.Host))
 
+	// Propagate correlation headers unless they are set explicitly
+	for k, h := range kathttp.CorrelationHeaders(ctx) {
+		if len(req.Headers.Values(k)) == 0 {
+			httpReq.Header[k] = h
+		}
+	}
 	if req.Headers != nil {
 		for k, h := range req.Headers {
 			for _, v := range h {
@@ -92,13 +109,21 @@ func DoBodyRequest(
 			}
 		}
 	}
+	logger.DebugContext(ctx, "performing request", headersAttr(httpReq.Header))
+	start := time.Now()
 	httpResp, err := client.Do(httpReq)
+	observeRequest(httpReq, httpResp, start)
 	if err != nil {
+		span.RecordError(err)
 		emsg := "error performing request"
 		logger.ErrorContext(ctx, emsg, "error", err)
 		return nil, fmt.Errorf("error %s: %w", emsg, err)
 	}
 	defer httpResp.Body.Close()
+	span.SetAttrs(slog.Int("http.response.status_code", httpResp.StatusCode))
+	if httpResp.StatusCode >= 400 {
+		span.RecordError(fmt.Errorf("HTTP status %d", httpResp.StatusCode))
+	}
 
 	resp := &BodyResponse{
 		IsSuccess: httpResp.StatusCode >= 200 && httpResp.StatusCode < 300,
@@ -119,6 +144,27 @@ func DoBodyRequest(
 	return resp, nil
 }
 
+// requestDuration is a latency of outbound requests per method, host and status ("error" if request failed)
+var requestDuration = katmetrics.Default.Histogram("http_client_request_duration_seconds",
+	"Latency of outbound HTTP requests", nil, "method", "host", "status")
+
+func observeRequest(req *http.Request, resp *http.Response, start time.Time) {
+	status := "error"
+	if resp != nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	requestDuration.With(kathttp.MetricsMethod(req.Method), req.URL.Host, status).ObserveSince(start)
+}
+
+// headersAttr represents headers as a log group, so values of sensitive headers are redacted by their names
+func headersAttr(headers http.Header) slog.Attr {
+	attrs := make([]any, 0, len(headers))
+	for name, values := range headers {
+		attrs = append(attrs, slog.String(name, strings.Join(values, ", ")))
+	}
+	return slog.Group("headers", attrs...)
+}
+
 func DoJsonRequest[TReqBody any, TRespBody any, TRespErr any](
 	ctx context.Context, client *http.Client, method string, reqURL string, req JsonRequest[TReqBody],
 ) (*JsonResponse[TRespBody, TRespErr], error) {
@@ -0,0 +1,149 @@
+package katmetrics
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ContentType is a content type of Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Handler serves metrics of the registry in Prometheus text exposition format
+func Handler(r *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var buf bytes.Buffer
+		if err := r.WriteText(&buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write(buf.Bytes())
+	})
+}
+
+// WriteText writes all metrics of the registry in Prometheus text exposition format.
+// Metrics are sorted by name and series by label values.
+func (r *Registry) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range r.sortedFamilies() {
+		f.writeText(bw)
+	}
+	return bw.Flush()
+}
+
+func (f *family) writeText(w *bufio.Writer) {
+	type sample struct {
+		labelValues []string
+		series      any
+		value       float64
+	}
+	f.mu.RLock()
+	samples := make([]sample, 0, len(f.series)+len(f.funcs))
+	for _, s := range f.series {
+		samples = append(samples, sample{labelValues: s.(labeledSeries).labels(), series: s})
+	}
+	funcs := make([]funcSeries, 0, len(f.funcs))
+	for _, fs := range f.funcs {
+		funcs = append(funcs, fs)
+	}
+	f.mu.RUnlock()
+	// functions are called without holding the lock, so they can use the registry too
+	for _, fs := range funcs {
+		samples = append(samples, sample{labelValues: fs.labelValues, value: fs.fn()})
+	}
+	if len(samples) == 0 {
+		return
+	}
+	slices.SortFunc(samples, func(a, b sample) int {
+		return slices.Compare(a.labelValues, b.labelValues)
+	})
+
+	if f.help != "" {
+		w.WriteString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+	}
+	w.WriteString("# TYPE " + f.name + " " + string(f.typ) + "\n")
+	for _, s := range samples {
+		switch series := s.series.(type) {
+		case *Counter:
+			f.writeSample(w, f.name, s.labelValues, "", series.Value())
+		case *Gauge:
+			f.writeSample(w, f.name, s.labelValues, "", series.Value())
+		case *Histogram:
+			cumulative, count, sum := series.snapshot()
+			for i, le := range f.buckets {
+				f.writeSample(w, f.name+"_bucket", s.labelValues, formatFloat(le), float64(cumulative[i]))
+			}
+			f.writeSample(w, f.name+"_bucket", s.labelValues, "+Inf", float64(count))
+			f.writeSample(w, f.name+"_sum", s.labelValues, "", sum)
+			f.writeSample(w, f.name+"_count", s.labelValues, "", float64(count))
+		default:
+			f.writeSample(w, f.name, s.labelValues, "", s.value)
+		}
+	}
+}
+
+// labeledSeries is implemented by Counter, Gauge and Histogram
+type labeledSeries interface {
+	labels() []string
+}
+
+func (c *Counter) labels() []string   { return c.labelValues }
+func (g *Gauge) labels() []string     { return g.labelValues }
+func (h *Histogram) labels() []string { return h.labelValues }
+
+// writeSample writes a single line of the series, le is a bucket label of histograms (omitted if empty)
+func (f *family) writeSample(w *bufio.Writer, name string, labelValues []string, le string, value float64) {
+	w.WriteString(name)
+	if len(labelValues) > 0 || le != "" {
+		w.WriteByte('{')
+		for i, lv := range labelValues {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(f.labelNames[i] + `="` + escapeLabelValue(lv) + `"`)
+		}
+		if le != "" {
+			if len(labelValues) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(`le="` + le + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+var (
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,184 @@
+package katmetrics
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefBuckets are default histogram buckets for latencies in seconds
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// CounterVec is a counter partitioned by label values
+type CounterVec struct {
+	f *family
+}
+
+// With returns counter of the label values (in the order of label names the vector was registered with)
+func (v *CounterVec) With(labelValues ...string) *Counter {
+	return v.f.get(labelValues, func() any {
+		return &Counter{labelValues: slices.Clone(labelValues)}
+	}).(*Counter)
+}
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	labelValues []string
+	value       atomicFloat
+}
+
+// Inc increments counter by 1
+func (c *Counter) Inc() {
+	c.value.add(1)
+}
+
+// Add increases counter by the value, it panics if the value is negative
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("counter cannot decrease")
+	}
+	c.value.add(v)
+}
+
+// Value returns current value of the counter
+func (c *Counter) Value() float64 {
+	return c.value.load()
+}
+
+// GaugeVec is a gauge partitioned by label values
+type GaugeVec struct {
+	f *family
+}
+
+// With returns gauge of the label values (in the order of label names the vector was registered with)
+func (v *GaugeVec) With(labelValues ...string) *Gauge {
+	return v.f.get(labelValues, func() any {
+		return &Gauge{labelValues: slices.Clone(labelValues)}
+	}).(*Gauge)
+}
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	labelValues []string
+	value       atomicFloat
+}
+
+// Set sets gauge to the value
+func (g *Gauge) Set(v float64) {
+	g.value.store(v)
+}
+
+// Add adds the value (can be negative) to the gauge
+func (g *Gauge) Add(v float64) {
+	g.value.add(v)
+}
+
+// Inc increments gauge by 1
+func (g *Gauge) Inc() {
+	g.value.add(1)
+}
+
+// Dec decrements gauge by 1
+func (g *Gauge) Dec() {
+	g.value.add(-1)
+}
+
+// Value returns current value of the gauge
+func (g *Gauge) Value() float64 {
+	return g.value.load()
+}
+
+// HistogramVec is a histogram partitioned by label values
+type HistogramVec struct {
+	f *family
+}
+
+// With returns histogram of the label values (in the order of label names the vector was registered with)
+func (v *HistogramVec) With(labelValues ...string) *Histogram {
+	return v.f.get(labelValues, func() any {
+		return &Histogram{
+			labelValues: slices.Clone(labelValues),
+			buckets:     v.f.buckets,
+			counts:      make([]uint64, len(v.f.buckets)),
+		}
+	}).(*Histogram)
+}
+
+// Histogram counts observed values in buckets
+type Histogram struct {
+	labelValues []string
+	buckets     []float64
+
+	mu     sync.Mutex
+	counts []uint64 // non-cumulative counts of buckets
+	count  uint64
+	sum    float64
+}
+
+// Observe adds the value to the histogram
+func (h *Histogram) Observe(v float64) {
+	i, _ := slices.BinarySearch(h.buckets, v)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// ObserveSince adds duration (in seconds) elapsed since the start time to the histogram
+func (h *Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+// snapshot returns cumulative counts of buckets, total count and sum of observed values
+func (h *Histogram) snapshot() ([]uint64, uint64, float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	cumulative := make([]uint64, len(h.counts))
+	var total uint64
+	for i, c := range h.counts {
+		total += c
+		cumulative[i] = total
+	}
+	return cumulative, h.count, h.sum
+}
+
+// Count returns number of observed values
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Sum returns sum of observed values
+func (h *Histogram) Sum() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sum
+}
+
+// atomicFloat is a float64 updated atomically
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+func (f *atomicFloat) store(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
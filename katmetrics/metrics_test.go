@@ -0,0 +1,150 @@
+package katmetrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/katmetrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeText(t *testing.T, reg *katmetrics.Registry) string {
+	var sb strings.Builder
+	require.NoError(t, reg.WriteText(&sb))
+	return sb.String()
+}
+
+func TestCounter(t *testing.T) {
+	reg := katmetrics.NewRegistry()
+	requests := reg.Counter("app_requests_total", "Number of requests", "method", "status")
+	requests.With("GET", "200").Inc()
+	requests.With("GET", "200").Add(2)
+	requests.With("POST", "500").Inc()
+
+	assert.Equal(t, 3.0, requests.With("GET", "200").Value())
+	assert.Equal(t, `# HELP app_requests_total Number of requests
+# TYPE app_requests_total counter
+app_requests_total{method="GET",status="200"} 3
+app_requests_total{method="POST",status="500"} 1
+`, writeText(t, reg))
+
+	assert.Panics(t, func() { requests.With("GET", "200").Add(-1) })
+	assert.Panics(t, func() { requests.With("GET") })
+}
+
+func TestGauge(t *testing.T) {
+	reg := katmetrics.NewRegistry()
+	inflight := reg.Gauge("app_inflight", "")
+	inflight.With().Set(5)
+	inflight.With().Inc()
+	inflight.With().Dec()
+	inflight.With().Add(-1.5)
+
+	assert.Equal(t, "# TYPE app_inflight gauge\napp_inflight 3.5\n", writeText(t, reg))
+}
+
+func TestHistogram(t *testing.T) {
+	reg := katmetrics.NewRegistry()
+	latency := reg.Histogram("app_latency_seconds", "Latency", []float64{0.1, 1}, "route")
+	h := latency.With("/users")
+	h.Observe(0.05)
+	h.Observe(0.1)
+	h.Observe(0.5)
+	h.Observe(3)
+
+	assert.EqualValues(t, 4, h.Count())
+	assert.InDelta(t, 3.65, h.Sum(), 1e-9)
+	assert.Equal(t, `# HELP app_latency_seconds Latency
+# TYPE app_latency_seconds histogram
+app_latency_seconds_bucket{route="/users",le="0.1"} 2
+app_latency_seconds_bucket{route="/users",le="1"} 3
+app_latency_seconds_bucket{route="/users",le="+Inf"} 4
+app_latency_seconds_sum{route="/users"} 3.65
+app_latency_seconds_count{route="/users"} 4
+`, writeText(t, reg))
+
+	assert.Panics(t, func() { reg.Histogram("unsorted", "", []float64{1, 0.1}) })
+	assert.Panics(t, func() { reg.Histogram("with_le", "", nil, "le") })
+}
+
+func TestFuncMetrics(t *testing.T) {
+	reg := katmetrics.NewRegistry()
+	conns := 3.0
+	reg.GaugeFunc("pool_conns", "Connections", katmetrics.Labels{"pool": "main"}, func() float64 { return conns })
+	reg.GaugeFunc("pool_conns", "Connections", katmetrics.Labels{"pool": "audit"}, func() float64 { return 1 })
+	reg.CounterFunc("pool_acquires_total", "Acquires", nil, func() float64 { return 42 })
+
+	conns = 4
+	assert.Equal(t, `# HELP pool_acquires_total Acquires
+# TYPE pool_acquires_total counter
+pool_acquires_total 42
+# HELP pool_conns Connections
+# TYPE pool_conns gauge
+pool_conns{pool="audit"} 1
+pool_conns{pool="main"} 4
+`, writeText(t, reg))
+
+	// registering the same series again replaces the function
+	reg.GaugeFunc("pool_conns", "Connections", katmetrics.Labels{"pool": "main"}, func() float64 { return 0 })
+	assert.Contains(t, writeText(t, reg), "pool_conns{pool=\"main\"} 0\n")
+}
+
+func TestRegistration(t *testing.T) {
+	reg := katmetrics.NewRegistry()
+	c1 := reg.Counter("jobs_total", "", "job")
+	c2 := reg.Counter("jobs_total", "", "job")
+	c1.With("cleanup").Inc()
+	assert.Equal(t, 1.0, c2.With("cleanup").Value())
+
+	assert.Panics(t, func() { reg.Gauge("jobs_total", "", "job") })
+	assert.Panics(t, func() { reg.Counter("jobs_total", "", "name") })
+	assert.Panics(t, func() { reg.Counter("invalid-name", "") })
+	assert.Panics(t, func() { reg.Counter("valid_name", "", "invalid-label") })
+}
+
+func TestEscaping(t *testing.T) {
+	reg := katmetrics.NewRegistry()
+	reg.Counter("errors_total", "Errors\nby \\ message", "message").With("say \"hi\"\n").Inc()
+
+	assert.Equal(t, `# HELP errors_total Errors\nby \\ message
+# TYPE errors_total counter
+errors_total{message="say \"hi\"\n"} 1
+`, writeText(t, reg))
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	reg := katmetrics.NewRegistry()
+	counter := reg.Counter("hits_total", "", "worker")
+	histogram := reg.Histogram("durations", "", nil)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				counter.With("w").Inc()
+				histogram.With().Observe(0.01)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 10000.0, counter.With("w").Value())
+	assert.EqualValues(t, 10000, histogram.With().Count())
+}
+
+func TestHandler(t *testing.T) {
+	reg := katmetrics.NewRegistry()
+	reg.Counter("hits_total", "Hits").With().Inc()
+
+	rec := httptest.NewRecorder()
+	katmetrics.Handler(reg).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, katmetrics.ContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, "# HELP hits_total Hits\n# TYPE hits_total counter\nhits_total 1\n", rec.Body.String())
+}
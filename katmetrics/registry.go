@@ -0,0 +1,180 @@
+package katmetrics
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Default is a registry used by built-in instrumentation of gokatana (HTTP servers and clients).
+// Expose it with Handler, e.g. kathttp_std.RegisterMetricsRoutes(mux, katmetrics.Default).
+var Default = NewRegistry()
+
+// Labels are constant labels of function metrics (see Registry.GaugeFunc)
+type Labels map[string]string
+
+type metricType string
+
+const (
+	counterType   metricType = "counter"
+	gaugeType     metricType = "gauge"
+	histogramType metricType = "histogram"
+)
+
+var (
+	metricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNamePattern  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Registry keeps metrics and writes them in Prometheus text exposition format (see WriteText).
+// Registering a metric with the same name, type and labels again returns the existing metric,
+// registering a conflicting metric panics.
+type Registry struct {
+	mu       sync.RWMutex
+	families map[string]*family
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// family is a metric with all its series (combinations of label values)
+type family struct {
+	name       string
+	help       string
+	typ        metricType
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.RWMutex
+	series map[string]any // *Counter, *Gauge or *Histogram by joined label values
+	funcs  map[string]funcSeries
+}
+
+// funcSeries is a series with value computed when metrics are collected
+type funcSeries struct {
+	labelValues []string
+	fn          func() float64
+}
+
+// Counter registers counter vector with the labels
+func (r *Registry) Counter(name string, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{f: r.register(name, help, counterType, labelNames, nil)}
+}
+
+// Gauge registers gauge vector with the labels
+func (r *Registry) Gauge(name string, help string, labelNames ...string) *GaugeVec {
+	return &GaugeVec{f: r.register(name, help, gaugeType, labelNames, nil)}
+}
+
+// Histogram registers histogram vector with upper bounds of buckets (DefBuckets if nil) and the labels
+func (r *Registry) Histogram(name string, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	if slices.Contains(labelNames, "le") {
+		panic(fmt.Sprintf("histogram %s must not have label \"le\"", name))
+	}
+	if !slices.IsSorted(buckets) || len(slices.Compact(slices.Clone(buckets))) != len(buckets) {
+		panic(fmt.Sprintf("buckets of histogram %s must be sorted in increasing order", name))
+	}
+	return &HistogramVec{f: r.register(name, help, histogramType, labelNames, slices.Clone(buckets))}
+}
+
+// GaugeFunc registers gauge series with the constant labels, its value is computed by fn every time
+// metrics are collected. Registering series with the same labels again replaces the function.
+func (r *Registry) GaugeFunc(name string, help string, labels Labels, fn func() float64) {
+	r.registerFunc(name, help, gaugeType, labels, fn)
+}
+
+// CounterFunc registers counter series with the constant labels, its value is computed by fn every time
+// metrics are collected (fn must return monotonically increasing values, e.g. counters of pgxpool.Stat).
+// Registering series with the same labels again replaces the function.
+func (r *Registry) CounterFunc(name string, help string, labels Labels, fn func() float64) {
+	r.registerFunc(name, help, counterType, labels, fn)
+}
+
+func (r *Registry) registerFunc(name string, help string, typ metricType, labels Labels, fn func() float64) {
+	labelNames := slices.Sorted(maps.Keys(labels))
+	labelValues := make([]string, len(labelNames))
+	for i, ln := range labelNames {
+		labelValues[i] = labels[ln]
+	}
+	f := r.register(name, help, typ, labelNames, nil)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.funcs[seriesKey(labelValues)] = funcSeries{labelValues: labelValues, fn: fn}
+}
+
+func (r *Registry) register(
+	name string, help string, typ metricType, labelNames []string, buckets []float64,
+) *family {
+	if !metricNamePattern.MatchString(name) {
+		panic(fmt.Sprintf("invalid metric name %q", name))
+	}
+	for _, ln := range labelNames {
+		if !labelNamePattern.MatchString(ln) || strings.HasPrefix(ln, "__") {
+			panic(fmt.Sprintf("invalid label name %q of metric %s", ln, name))
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		if f.typ != typ || !slices.Equal(f.labelNames, labelNames) || !slices.Equal(f.buckets, buckets) {
+			panic(fmt.Sprintf("metric %s was already registered as %s with labels %v", name, f.typ, f.labelNames))
+		}
+		return f
+	}
+	f := &family{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: slices.Clone(labelNames),
+		buckets:    buckets,
+		series:     make(map[string]any),
+		funcs:      make(map[string]funcSeries),
+	}
+	r.families[name] = f
+	return f
+}
+
+// sortedFamilies returns registered metrics sorted by name
+func (r *Registry) sortedFamilies() []*family {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	families := slices.Collect(maps.Values(r.families))
+	slices.SortFunc(families, func(a, b *family) int {
+		return strings.Compare(a.name, b.name)
+	})
+	return families
+}
+
+// get returns series of the label values, it is created with newSeries if it does not exist
+func (f *family) get(labelValues []string, newSeries func() any) any {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", f.name, len(f.labelNames), len(labelValues)))
+	}
+	key := seriesKey(labelValues)
+	f.mu.RLock()
+	s, ok := f.series[key]
+	f.mu.RUnlock()
+	if ok {
+		return s
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok := f.series[key]; ok {
+		return s
+	}
+	s = newSeries()
+	f.series[key] = s
+	return s
+}
+
+func seriesKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
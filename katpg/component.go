@@ -0,0 +1,109 @@
+package katpg
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/mobiletoly/gokatana/katapp"
+)
+
+// Component returns application component (see katapp.App) that closes the connection pool when
+// application is stopped, it is ready while database responds to pings
+func (db *DBLink) Component() katapp.Component {
+	return &dbComponent{db: db}
+}
+
+type dbComponent struct {
+	db *DBLink
+}
+
+func (c *dbComponent) Name() string {
+	return "postgres"
+}
+
+func (c *dbComponent) Start(ctx context.Context) error {
+	return c.db.Ping(ctx)
+}
+
+func (c *dbComponent) Stop(ctx context.Context) error {
+	c.db.Close()
+	return nil
+}
+
+func (c *dbComponent) Ready(ctx context.Context) error {
+	return c.db.Ping(ctx)
+}
+
+// Component returns application component (see katapp.App) that runs cache runners of all registered
+// collections (see Run) and waits for them to exit when application is stopped
+func (c *KVTCache) Component() katapp.Component {
+	return &kvtCacheComponent{cache: c}
+}
+
+type kvtCacheComponent struct {
+	cache  *KVTCache
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func (c *kvtCacheComponent) Name() string {
+	return "kvtcache"
+}
+
+func (c *kvtCacheComponent) Start(ctx context.Context) error {
+	ctx, c.cancel = context.WithCancel(ctx)
+	for _, chunk := range c.cache.chunks {
+		c.wg.Go(func() {
+			c.cache.Run(ctx, chunk.coll)
+		})
+	}
+	return nil
+}
+
+func (c *kvtCacheComponent) Stop(ctx context.Context) error {
+	c.cancel()
+	c.wg.Wait()
+	return nil
+}
+
+// Component returns application component (see katapp.App) that competes for leadership while
+// application is running (see Start). Failure to acquire a connection fails start of the component,
+// later errors of leader election are logged.
+func (le *LeaderElector) Component() katapp.Component {
+	return &leaderComponent{le: le}
+}
+
+type leaderComponent struct {
+	le   *LeaderElector
+	done chan struct{}
+}
+
+func (c *leaderComponent) Name() string {
+	return "leader"
+}
+
+func (c *leaderComponent) Start(ctx context.Context) error {
+	errCh := c.le.Start(ctx)
+	if !c.le.running.Load() {
+		return <-errCh
+	}
+	c.done = make(chan struct{})
+	go func() {
+		defer close(c.done)
+		for err := range errCh {
+			katapp.Logger(ctx).WarnContext(ctx, "leader election error", "error", err)
+		}
+	}()
+	return nil
+}
+
+func (c *leaderComponent) Stop(ctx context.Context) error {
+	c.le.Stop()
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return errors.New("leader election did not stop in time")
+	}
+}
@@ -57,6 +57,7 @@ func Connect(ctx context.Context, cfg *katapp.DatabaseConfig) (*DBLink, error) {
 	pcfg.MaxConnIdleTime = cfg.Pool.MaxConnIdleTime
 	pcfg.MaxConnLifetime = cfg.Pool.MaxConnLifetime
 	pcfg.HealthCheckPeriod = cfg.Pool.HealthPeriod
+	pcfg.ConnConfig.Tracer = QueryTracer{}
 
 	initCtx, cancel := context.WithTimeout(ctx, 8*time.Second)
 	defer cancel()
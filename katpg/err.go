@@ -3,6 +3,7 @@ package katpg
 import (
 	"context"
 	"errors"
+	"regexp"
 	"strings"
 
 	"github.com/jackc/pgx/v5"
@@ -10,42 +11,55 @@ import (
 	"github.com/mobiletoly/gokatana/katapp"
 )
 
+// PgToAppError converts database error to application error (original error is kept as its cause)
 func PgToAppError(err error, title string) *katapp.Err {
 	if IsNoRows(err) {
-		return katapp.NewErr(katapp.ErrNotFound, title+": not found")
+		return katapp.Wrap(katapp.ErrNotFound, err, "%s: not found", title)
 	}
 	var pgerr *pgconn.PgError
 	if errors.As(err, &pgerr) {
 		switch pgerr.Code {
 		case "23505":
-			return katapp.NewErr(katapp.ErrDuplicate, title+": duplicate data")
+			return katapp.Wrap(katapp.ErrDuplicate, err, "%s: duplicate data", title)
 		case "23503":
 			if strings.Contains(pgerr.Message, "update") || strings.Contains(pgerr.Message, "delete") {
 				if strings.Contains(pgerr.Detail, "is not present") {
-					return katapp.NewErr(katapp.ErrNotFound, title+": referenced record not found")
+					return katapp.Wrap(katapp.ErrNotFound, err, "%s: referenced record not found", title)
 				} else {
-					return katapp.NewErr(katapp.ErrConflict, ": record is in use by other records")
+					return katapp.Wrap(katapp.ErrConflict, err, ": record is in use by other records")
 				}
 			} else {
-				return katapp.NewErr(katapp.ErrNotFound, title+": referenced record not found")
+				return katapp.Wrap(katapp.ErrNotFound, err, "%s: referenced record not found", title)
 			}
+		case "40001", "40P01":
+			// serialization failure or deadlock, transaction can be retried
+			return katapp.Wrap(katapp.ErrInternal, err, "%s: transaction conflict", title).WithRetry(0)
 		default:
-			return katapp.NewErr(katapp.ErrInternal, title+": unknown error")
+			return katapp.Wrap(katapp.ErrInternal, err, "%s: unknown error", title)
 		}
 	}
-	return katapp.NewErr(katapp.ErrInternal, title+": unknown error")
+	return katapp.Wrap(katapp.ErrInternal, err, "%s: unknown error", title)
 }
 
 func PgToAppErrorContext(ctx context.Context, err error, title string) *katapp.Err {
 	var pgerr *pgconn.PgError
 	var details string
 	if errors.As(err, &pgerr) {
-		details = pgerr.Detail
+		details = redactPgDetail(pgerr.Detail)
 	}
-	katapp.Logger(ctx).Error("katpg.LoggedPgToAppError", "title", title, "error", err, "details", details)
+	katapp.Logger(ctx).Redacted().Error("katpg.LoggedPgToAppError", "title", title, "error", err, "details", details)
 	return PgToAppError(err, title)
 }
 
+// pgDetailValuesPattern matches row data in error details, e.g. "Key (email)=(user@example.com) already exists."
+// or "Failing row contains (1, user@example.com, null)."
+var pgDetailValuesPattern = regexp.MustCompile(`(\)=\(|row contains \()(.*)\)`)
+
+// redactPgDetail masks row data in details of PostgreSQL error, names of columns are kept
+func redactPgDetail(detail string) string {
+	return pgDetailValuesPattern.ReplaceAllString(detail, "${1}"+katapp.RedactedValue+")")
+}
+
 func IsNoRows(err error) bool {
 	return err != nil && errors.Is(err, pgx.ErrNoRows)
 }
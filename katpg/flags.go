@@ -0,0 +1,174 @@
+package katpg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mobiletoly/gokatana/katapp"
+)
+
+// flagStoreRetryDelay is a delay before listening for flag changes again after connection failure
+const flagStoreRetryDelay = 5 * time.Second
+
+// FlagStore keeps runtime overrides of feature flags in a database table and applies them to katapp.Flags.
+// Changes made with Set and Delete are propagated to all instances with LISTEN/NOTIFY (see Run).
+type FlagStore struct {
+	db      *pgxpool.Pool
+	flags   *katapp.Flags
+	channel string
+	logger  *slog.Logger
+
+	selectSql string
+	upsertSql string
+	deleteSql string
+}
+
+// NewFlagStore returns a store of runtime overrides for the flags. Table feature_flags must already exist
+// in the schema, it is expected to be created by a migration of the application (see DBLink.DoMigration), e.g.
+//
+//	CREATE TABLE IF NOT EXISTS <schema>.feature_flags
+//	(
+//	    name       TEXT PRIMARY KEY,
+//	    config     JSONB       NOT NULL,
+//	    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+//	);
+func NewFlagStore(ctx context.Context, db *pgxpool.Pool, schema string, flags *katapp.Flags) (*FlagStore, error) {
+	table := pgx.Identifier{schema, "feature_flags"}.Sanitize()
+	s := &FlagStore{
+		db:      db,
+		flags:   flags,
+		channel: schema + "_feature_flags",
+		logger:  katapp.Logger(ctx).WithGroup("katpg.FlagStore").Logger,
+		selectSql: fmt.Sprintf(`
+SELECT name, config
+FROM %s
+`, table),
+		upsertSql: fmt.Sprintf(`
+INSERT INTO %s(name, config, updated_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (name) DO UPDATE
+    SET config     = EXCLUDED.config,
+        updated_at = NOW()
+`, table),
+		deleteSql: fmt.Sprintf(`
+DELETE FROM %s
+WHERE name = $1
+`, table),
+	}
+	var exists bool
+	if err := db.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", table).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check feature flags table: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("feature flags table %s does not exist (it must be created by a migration)", table)
+	}
+	return s, nil
+}
+
+// Load loads overrides from the table and applies them to the flags
+func (s *FlagStore) Load(ctx context.Context) error {
+	rows, _ := s.db.Query(ctx, s.selectSql)
+	var overrides []katapp.FlagConfig
+	var name string
+	var data []byte
+	_, err := pgx.ForEachRow(rows, []any{&name, &data}, func() error {
+		var cfg katapp.FlagConfig
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return fmt.Errorf("invalid configuration of feature flag %s: %w", name, err)
+		}
+		cfg.Name = name
+		overrides = append(overrides, cfg)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load feature flags: %w", err)
+	}
+	return s.flags.SetOverrides(overrides)
+}
+
+// Set overrides feature flag and notifies all instances about the change
+func (s *FlagStore) Set(ctx context.Context, cfg katapp.FlagConfig) error {
+	if vs := katapp.Validate(&cfg); len(vs) > 0 {
+		return katapp.NewErr(katapp.ErrInvalidInput, "invalid feature flag").WithViolations(vs...)
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode feature flag %s: %w", cfg.Name, err)
+	}
+	return s.change(ctx, cfg.Name, s.upsertSql, cfg.Name, data)
+}
+
+// Delete removes override of feature flag (flag defined in configuration is used again)
+// and notifies all instances about the change
+func (s *FlagStore) Delete(ctx context.Context, name string) error {
+	return s.change(ctx, name, s.deleteSql, name)
+}
+
+func (s *FlagStore) change(ctx context.Context, name string, sql string, args ...any) error {
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, "SELECT pg_notify($1, $2)", s.channel, name)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to change feature flag %s: %w", name, err)
+	}
+	return s.Load(ctx)
+}
+
+// Run is a blocking runner that loads overrides and listens for their changes made by other instances.
+// Connection failures are logged and listening is resumed after a delay. Cancelling the context
+// will stop the runner.
+func (s *FlagStore) Run(ctx context.Context) {
+	s.logger.InfoContext(ctx, "starting feature flags listener")
+loop:
+	for {
+		err := s.listen(ctx)
+		if ctx.Err() != nil {
+			break
+		}
+		s.logger.ErrorContext(ctx, "feature flags listener has failed", "error", err)
+		select {
+		case <-time.After(flagStoreRetryDelay):
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	s.logger.InfoContext(ctx, "stopped feature flags listener")
+}
+
+func (s *FlagStore) listen(ctx context.Context) error {
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+	channel := pgx.Identifier{s.channel}.Sanitize()
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return fmt.Errorf("failed to listen for feature flag changes: %w", err)
+	}
+	defer func() {
+		_, _ = conn.Exec(context.WithoutCancel(ctx), "UNLISTEN "+channel)
+	}()
+	// changes made before listening started are loaded here
+	if err := s.Load(ctx); err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		s.logger.DebugContext(ctx, "feature flag was changed", "flag", notification.Payload)
+		if err := s.Load(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "failed to reload feature flags", "error", err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package katpg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/kattest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlagStore_PropagatesOverrides(t *testing.T) {
+	ctx, cancel := context.WithCancel(kattest.AppTestContext())
+	defer cancel()
+	pc := RunPostgresTestContainer(ctx, t, nil, nil)
+	pool := pc.BuildPgxPool(ctx, t)
+	t.Cleanup(func() {
+		defer pool.Close()
+		pc.Terminate(ctx, t)
+	})
+
+	_, err := NewFlagStore(ctx, pool, "public", nil)
+	assert.ErrorContains(t, err, "does not exist")
+	_, err = pool.Exec(ctx, `
+CREATE TABLE public.feature_flags
+(
+    name       TEXT PRIMARY KEY,
+    config     JSONB       NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+)`)
+	require.NoError(t, err)
+
+	defined := []katapp.FlagConfig{{Name: "new-checkout"}}
+	writerFlags, err := katapp.NewFlags(defined)
+	require.NoError(t, err)
+	readerFlags, err := katapp.NewFlags(defined)
+	require.NoError(t, err)
+	writer, err := NewFlagStore(ctx, pool, "public", writerFlags)
+	require.NoError(t, err)
+	reader, err := NewFlagStore(ctx, pool, "public", readerFlags)
+	require.NoError(t, err)
+	go reader.Run(ctx)
+
+	require.NoError(t, writer.Set(ctx, katapp.FlagConfig{Name: "new-checkout", Enabled: true}))
+	assert.True(t, writerFlags.Enabled(ctx, "new-checkout"))
+	assert.Eventually(t, func() bool {
+		return readerFlags.Enabled(ctx, "new-checkout")
+	}, 5*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, writer.Delete(ctx, "new-checkout"))
+	assert.Eventually(t, func() bool {
+		return !readerFlags.Enabled(ctx, "new-checkout")
+	}, 5*time.Second, 50*time.Millisecond)
+
+	assert.Error(t, writer.Set(ctx, katapp.FlagConfig{Name: "bad", Rollout: 200}))
+}
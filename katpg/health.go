@@ -0,0 +1,77 @@
+package katpg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mobiletoly/gokatana/katapp"
+)
+
+// HealthCheck returns critical health check that pings database (with pool statistics as details)
+func (db *DBLink) HealthCheck() katapp.HealthCheck {
+	return katapp.HealthCheck{
+		Name:     "postgres",
+		Check:    db.Ping,
+		Critical: true,
+		Details: func() map[string]any {
+			stat := db.Stat()
+			return map[string]any{
+				"totalConns":    stat.TotalConns(),
+				"idleConns":     stat.IdleConns(),
+				"acquiredConns": stat.AcquiredConns(),
+				"maxConns":      stat.MaxConns(),
+			}
+		},
+	}
+}
+
+// HealthCheck returns non-critical health check that fails if leader election is not running
+// (leadership state is reported as details)
+func (le *LeaderElector) HealthCheck() katapp.HealthCheck {
+	return katapp.HealthCheck{
+		Name: "leader",
+		Check: func(ctx context.Context) error {
+			if !le.running.Load() {
+				return errors.New("leader election is not running")
+			}
+			return nil
+		},
+		Details: func() map[string]any {
+			return map[string]any{"leader": le.IsLeader()}
+		},
+	}
+}
+
+// HealthCheck returns readiness health check that fails if cache runner of any registered collection
+// has stalled (see Run). Collections without running runners (not started yet or stopped) are reported
+// as details only.
+func (c *KVTCache) HealthCheck() katapp.HealthCheck {
+	return katapp.HealthCheck{
+		Name: "kvtcache",
+		Check: func(ctx context.Context) error {
+			var errs []error
+			for name, chunk := range c.chunks {
+				beat, ok := c.beats.Load(name)
+				if !ok {
+					continue
+				}
+				if since := time.Since(beat.(time.Time)); since > 2*chunk.coll.Ttl+time.Minute {
+					errs = append(errs, fmt.Errorf("cache runner for collection=%s has stalled for %s", name, since))
+				}
+			}
+			return errors.Join(errs...)
+		},
+		Critical: true,
+		Details: func() map[string]any {
+			running := 0
+			for name := range c.chunks {
+				if _, ok := c.beats.Load(name); ok {
+					running++
+				}
+			}
+			return map[string]any{"collections": len(c.chunks), "runningRunners": running}
+		},
+	}
+}
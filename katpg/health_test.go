@@ -0,0 +1,28 @@
+package katpg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mobiletoly/gokatana/katcache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKVTCacheHealthCheck(t *testing.T) {
+	coll := katcache.Collection{Name: "users", Ttl: time.Minute}
+	c := &KVTCache{chunks: map[string]dbCacheChunk{coll.Name: {coll: coll}}}
+	check := c.HealthCheck()
+	assert.False(t, check.Liveness)
+
+	// runner has not started yet
+	assert.NoError(t, check.Check(context.Background()))
+	assert.Equal(t, map[string]any{"collections": 1, "runningRunners": 0}, check.Details())
+
+	c.beats.Store(coll.Name, time.Now())
+	assert.NoError(t, check.Check(context.Background()))
+	assert.Equal(t, map[string]any{"collections": 1, "runningRunners": 1}, check.Details())
+
+	c.beats.Store(coll.Name, time.Now().Add(-time.Hour))
+	assert.ErrorContains(t, check.Check(context.Background()), "cache runner for collection=users has stalled")
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/mobiletoly/gokatana/katcache"
 	"log"
 	"log/slog"
+	"sync"
 	"time"
 )
 
@@ -20,6 +21,7 @@ type KVTCache struct {
 	chunks          map[string]dbCacheChunk
 	logger          *slog.Logger
 	approveDeletion func(ctx context.Context, coll katcache.Collection) bool
+	beats           sync.Map // collection name -> time.Time of the last runner iteration
 }
 
 type dbCacheChunk struct {
@@ -141,11 +143,14 @@ func (c *KVTCache) Run(
 	}
 	ticker := time.NewTicker(coll.Ttl)
 	defer ticker.Stop()
+	c.beats.Store(coll.Name, time.Now())
+	defer c.beats.Delete(coll.Name)
 
 loop:
 	for {
 		select {
 		case <-ticker.C:
+			c.beats.Store(coll.Name, time.Now())
 			if c.approveDeletion == nil || c.approveDeletion(ctx, coll) {
 				c.logger.DebugContext(ctx, "deleting expired data",
 					"collection", coll.Name, "ttl", coll.Ttl.String())
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -18,7 +19,8 @@ type LeaderElector struct {
 	heartbeatPeriod time.Duration
 
 	cancel   context.CancelFunc
-	isLeader bool
+	isLeader atomic.Bool
+	running  atomic.Bool
 	errCh    chan error
 }
 
@@ -51,6 +53,7 @@ func (le *LeaderElector) Start(ctx context.Context) <-chan error {
 	}
 	le.conn = conn
 
+	le.running.Store(true)
 	go le.run(ctx)
 	return le.errCh
 }
@@ -64,7 +67,7 @@ func (le *LeaderElector) Stop() {
 
 // IsLeader returns whether we currently believe we are leader.
 func (le *LeaderElector) IsLeader() bool {
-	return le.isLeader
+	return le.isLeader.Load()
 }
 
 // run is the background routine that handles acquiring and confirming leadership.
@@ -77,41 +80,43 @@ func (le *LeaderElector) run(ctx context.Context) {
 		if le.conn != nil {
 			le.conn.Release()
 		}
+		le.isLeader.Store(false)
+		le.running.Store(false)
 		close(le.errCh)
 	}()
 
 	for {
 		select {
 		case <-ctx.Done():
-			if le.isLeader {
+			if le.isLeader.Load() {
 				_ = le.unlock()
 			}
 			return
 
 		// Periodically try to acquire if not leader
 		case <-acquireTicker.C:
-			if !le.isLeader {
+			if !le.isLeader.Load() {
 				acquired, err := le.tryLock(ctx)
 				if err != nil {
 					le.errCh <- fmt.Errorf("failed to acquire advisory lock: %w", err)
 				} else if acquired {
-					le.isLeader = true
+					le.isLeader.Store(true)
 				}
 			}
 
 		// Periodically confirm we still hold the lock if we are leader
 		case <-heartbeatTicker.C:
-			if le.isLeader {
+			if le.isLeader.Load() {
 				stillHeld, err := le.checkLockHeld(ctx)
 				if err != nil {
 					// If the check fails, we give up leadership
 					//le.errCh <- fmt.Errorf("heartbeat check failed: %w", err)
 					_, _ = fmt.Fprintf(os.Stderr, "1. heartbeat check failed: %v", err)
-					le.isLeader = false
+					le.isLeader.Store(false)
 				} else if !stillHeld {
 					_, _ = fmt.Fprintf(os.Stderr, "2. heartbeat check failed: %v", err)
 					// We lost the lock unexpectedly
-					le.isLeader = false
+					le.isLeader.Store(false)
 				}
 			}
 		}
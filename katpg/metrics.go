@@ -0,0 +1,64 @@
+package katpg
+
+import (
+	"strconv"
+
+	"github.com/mobiletoly/gokatana/katmetrics"
+)
+
+// RegisterMetrics registers connection pool statistics (pgxpool.Stat) in the registry,
+// series are labeled with the database name
+func (db *DBLink) RegisterMetrics(reg *katmetrics.Registry) {
+	labels := katmetrics.Labels{"database": db.cfg.Name}
+	gauges := []struct {
+		name  string
+		help  string
+		value func() float64
+	}{
+		{"katpg_pool_acquired_conns", "Number of connections currently acquired from the pool",
+			func() float64 { return float64(db.Stat().AcquiredConns()) }},
+		{"katpg_pool_idle_conns", "Number of idle connections in the pool",
+			func() float64 { return float64(db.Stat().IdleConns()) }},
+		{"katpg_pool_constructing_conns", "Number of connections being established",
+			func() float64 { return float64(db.Stat().ConstructingConns()) }},
+		{"katpg_pool_total_conns", "Total number of connections in the pool",
+			func() float64 { return float64(db.Stat().TotalConns()) }},
+		{"katpg_pool_max_conns", "Maximum size of the pool",
+			func() float64 { return float64(db.Stat().MaxConns()) }},
+	}
+	for _, g := range gauges {
+		reg.GaugeFunc(g.name, g.help, labels, g.value)
+	}
+	counters := []struct {
+		name  string
+		help  string
+		value func() float64
+	}{
+		{"katpg_pool_acquires_total", "Number of successful acquires from the pool",
+			func() float64 { return float64(db.Stat().AcquireCount()) }},
+		{"katpg_pool_acquire_duration_seconds_total", "Total duration of successful acquires from the pool",
+			func() float64 { return db.Stat().AcquireDuration().Seconds() }},
+		{"katpg_pool_empty_acquires_total", "Number of acquires that waited for a connection",
+			func() float64 { return float64(db.Stat().EmptyAcquireCount()) }},
+		{"katpg_pool_canceled_acquires_total", "Number of acquires canceled by context",
+			func() float64 { return float64(db.Stat().CanceledAcquireCount()) }},
+		{"katpg_pool_new_conns_total", "Number of established connections",
+			func() float64 { return float64(db.Stat().NewConnsCount()) }},
+	}
+	for _, c := range counters {
+		reg.CounterFunc(c.name, c.help, labels, c.value)
+	}
+}
+
+// RegisterMetrics registers leadership gauge (1 if this instance is a leader, 0 otherwise)
+// in the registry, series is labeled with the advisory lock key
+func (le *LeaderElector) RegisterMetrics(reg *katmetrics.Registry) {
+	reg.GaugeFunc("katpg_leader", "Whether this instance is a leader (1) or not (0)",
+		katmetrics.Labels{"lock_key": strconv.FormatInt(le.lockKey, 10)},
+		func() float64 {
+			if le.IsLeader() {
+				return 1
+			}
+			return 0
+		})
+}
@@ -0,0 +1,43 @@
+package katpg
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mobiletoly/gokatana/katapp"
+	"github.com/mobiletoly/gokatana/katmetrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	pc := RunPostgresTestContainer(ctx, t, nil, nil)
+	t.Cleanup(func() {
+		pc.Terminate(ctx, t)
+	})
+	pool := pc.BuildPgxPool(ctx, t)
+	defer pool.Close()
+
+	db := &DBLink{Pool: pool, cfg: &katapp.DatabaseConfig{Name: "testdb"}}
+	elector := NewLeaderElector(pool, 4321, 100*time.Millisecond, time.Second)
+	reg := katmetrics.NewRegistry()
+	db.RegisterMetrics(reg)
+	elector.RegisterMetrics(reg)
+
+	require.NoError(t, db.Ping(ctx))
+	var sb strings.Builder
+	require.NoError(t, reg.WriteText(&sb))
+	assert.Contains(t, sb.String(), "katpg_leader{lock_key=\"4321\"} 0\n")
+	assert.Contains(t, sb.String(), "# TYPE katpg_pool_acquires_total counter\n")
+	assert.Contains(t, sb.String(), "katpg_pool_max_conns{database=\"testdb\"} ")
+
+	elector.Start(ctx)
+	defer elector.Stop()
+	require.Eventually(t, elector.IsLeader, 5*time.Second, 100*time.Millisecond)
+	sb.Reset()
+	require.NoError(t, reg.WriteText(&sb))
+	assert.Contains(t, sb.String(), "katpg_leader{lock_key=\"4321\"} 1\n")
+}
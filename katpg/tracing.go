@@ -0,0 +1,50 @@
+package katpg
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mobiletoly/gokatana/kattrace"
+)
+
+var _ pgx.QueryTracer = QueryTracer{}
+
+type querySpanKey struct{}
+
+// QueryTracer is a pgx tracer that creates child spans of queries (it is installed by Connect).
+// Queries performed without a span in the context (e.g. by background runners) are not traced.
+type QueryTracer struct{}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if !kattrace.SpanContextFrom(ctx).IsValid() {
+		return ctx
+	}
+	operation := queryOperation(data.SQL)
+	ctx, span := kattrace.Start(ctx, operation, kattrace.SpanKindClient,
+		slog.String("db.system", "postgresql"),
+		slog.String("db.operation", operation),
+		slog.String("db.statement", data.SQL),
+	)
+	return context.WithValue(ctx, querySpanKey{}, span)
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span, ok := ctx.Value(querySpanKey{}).(*kattrace.Span)
+	if !ok {
+		return
+	}
+	span.SetAttrs(slog.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	span.RecordError(data.Err)
+	span.End()
+}
+
+// queryOperation returns SQL command of the query (e.g. "SELECT"), it is used as a span name
+func queryOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(fields[0])
+}
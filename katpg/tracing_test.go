@@ -0,0 +1,40 @@
+package katpg
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mobiletoly/gokatana/kattrace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryTracer(t *testing.T) {
+	exporter := kattrace.NewInMemoryExporter()
+	tracer := kattrace.NewTracer(exporter, 1)
+	qt := QueryTracer{}
+
+	// queries without a span in the context are not traced
+	ctx := qt.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	qt.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	assert.Empty(t, exporter.Spans())
+
+	ctx, root := tracer.Start(context.Background(), "GET", kattrace.SpanKindServer)
+	queryCtx := qt.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "\n  update users SET name = $1"})
+	qt.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{
+		CommandTag: pgconn.NewCommandTag("UPDATE 2"),
+		Err:        errors.New("deadlock detected"),
+	})
+	root.End()
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "UPDATE", spans[0].Name)
+	assert.Equal(t, root.SpanContext(), spans[0].Parent)
+	assert.EqualError(t, spans[0].Err, "deadlock detected")
+	rows, _ := spans[0].Attr("db.rows_affected")
+	assert.EqualValues(t, 2, rows.Int64())
+}
@@ -0,0 +1,18 @@
+package katredis
+
+import (
+	"context"
+
+	"github.com/mobiletoly/gokatana/katapp"
+)
+
+// HealthCheck returns critical health check that pings redis server
+func (adp *RedisCache) HealthCheck() katapp.HealthCheck {
+	return katapp.HealthCheck{
+		Name: "redis",
+		Check: func(ctx context.Context) error {
+			return adp.client.Ping(ctx).Err()
+		},
+		Critical: true,
+	}
+}
@@ -0,0 +1,116 @@
+package kattrace
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+// TraceID identifies a trace
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+// IsValid checks if trace ID is not all zeros
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid checks if span ID is not all zeros
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(id[8:], rand.Uint64())
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:], rand.Uint64())
+	}
+	return id
+}
+
+// SpanContext identifies a span and carries trace state propagated to other services
+// (W3C Trace Context, see https://www.w3.org/TR/trace-context/)
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	// Sampled is set if spans of the trace are recorded
+	Sampled bool
+	// TraceState is a vendor-specific trace state ("tracestate" header), it is propagated as is
+	TraceState string
+	// Remote is set if span context was received from another service
+	Remote bool
+}
+
+// IsValid checks if span context has valid trace and span IDs
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent formats span context as a value of W3C "traceparent" header
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent parses value of W3C "traceparent" header (version-format-id-flags), the returned
+// span context is marked as remote
+func ParseTraceparent(header string) (SpanContext, error) {
+	header = strings.TrimSpace(header)
+	// future versions can append fields, but must keep the layout of version 00
+	if len(header) < 55 || (len(header) > 55 && (header[:2] == "00" || header[55] != '-')) {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q: unexpected length", header)
+	}
+	if header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q: unexpected format", header)
+	}
+	var version, flags [1]byte
+	var sc SpanContext
+	if err := decodeHex(version[:], header[:2]); err != nil || version[0] == 0xff {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q: invalid version", header)
+	}
+	if err := decodeHex(sc.TraceID[:], header[3:35]); err != nil || !sc.TraceID.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q: invalid trace ID", header)
+	}
+	if err := decodeHex(sc.SpanID[:], header[36:52]); err != nil || !sc.SpanID.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q: invalid parent ID", header)
+	}
+	if err := decodeHex(flags[:], header[53:55]); err != nil {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q: invalid flags", header)
+	}
+	sc.Sampled = flags[0]&1 == 1
+	sc.Remote = true
+	return sc, nil
+}
+
+// decodeHex decodes lowercase hex string (uppercase is not allowed by W3C Trace Context)
+func decodeHex(dst []byte, s string) error {
+	if strings.ToLower(s) != s {
+		return fmt.Errorf("uppercase hex %q", s)
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err
+}
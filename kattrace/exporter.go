@@ -0,0 +1,42 @@
+package kattrace
+
+import (
+	"slices"
+	"sync"
+)
+
+var _ Exporter = (*InMemoryExporter)(nil)
+
+// InMemoryExporter keeps finished spans in memory, it is intended for tests, e.g.
+//
+//	exporter := kattrace.NewInMemoryExporter()
+//	kattrace.SetDefaultTracer(kattrace.NewTracer(exporter, 1))
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// NewInMemoryExporter creates exporter without spans
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+func (e *InMemoryExporter) ExportSpan(span SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns finished spans in the order they ended
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.spans)
+}
+
+// Reset removes all spans
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
@@ -0,0 +1,229 @@
+package kattrace
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Log attribute keys of trace and span IDs
+const (
+	TraceIdKey = "traceId"
+	SpanIdKey  = "spanId"
+)
+
+// SpanKind describes relationship of the span to its parent and children
+type SpanKind int
+
+const (
+	// SpanKindInternal is an internal operation of a service (e.g. cache lookup or database query)
+	SpanKindInternal SpanKind = iota
+	// SpanKindServer is a handling of incoming request
+	SpanKindServer
+	// SpanKindClient is an outgoing request to another service
+	SpanKindClient
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
+
+// SpanData is a finished span passed to exporters
+type SpanData struct {
+	Name        string
+	Kind        SpanKind
+	SpanContext SpanContext
+	// Parent is a span context of the parent span (invalid for root spans)
+	Parent SpanContext
+	Start  time.Time
+	End    time.Time
+	Attrs  []slog.Attr
+	// Err is an error the operation has failed with (nil if it has succeeded)
+	Err error
+}
+
+// Duration returns duration of the span
+func (d *SpanData) Duration() time.Duration {
+	return d.End.Sub(d.Start)
+}
+
+// Attr returns value of attribute with the key
+func (d *SpanData) Attr(key string) (slog.Value, bool) {
+	for _, a := range d.Attrs {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+// Exporter receives finished sampled spans. ExportSpan is called synchronously when span ends,
+// so exporters must not block (e.g. they should batch spans and send them in background).
+type Exporter interface {
+	ExportSpan(span SpanData)
+}
+
+// Tracer starts spans and passes finished sampled spans to the exporter. Spans of traces that are not
+// sampled (or all spans if there is no exporter) are not recorded, but their IDs are still propagated.
+type Tracer struct {
+	exporter    Exporter
+	sampleRatio float64
+}
+
+// NewTracer creates tracer that samples sampleRatio (0-1) of new traces and all traces sampled by callers
+// (parent-based sampling), sampled spans are passed to the exporter
+func NewTracer(exporter Exporter, sampleRatio float64) *Tracer {
+	return &Tracer{exporter: exporter, sampleRatio: sampleRatio}
+}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+func init() {
+	defaultTracer.Store(NewTracer(nil, 0))
+}
+
+// DefaultTracer returns tracer used by Start and built-in instrumentation of gokatana
+// (by default spans are not exported, only trace context is propagated)
+func DefaultTracer() *Tracer {
+	return defaultTracer.Load()
+}
+
+// SetDefaultTracer replaces tracer used by Start and built-in instrumentation of gokatana
+func SetDefaultTracer(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// Start starts a span with the tracer of the current span of the context, or with the default tracer
+// if there is no current span (see Tracer.Start)
+func Start(ctx context.Context, name string, kind SpanKind, attrs ...slog.Attr) (context.Context, *Span) {
+	if span, ok := SpanFrom(ctx); ok {
+		return span.tracer.Start(ctx, name, kind, attrs...)
+	}
+	return DefaultTracer().Start(ctx, name, kind, attrs...)
+}
+
+// Start starts a span as a child of the current span of the context (or of the remote span context,
+// see ContextWithRemoteSpanContext) and returns a new context with the span. Span must be ended with End.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, attrs ...slog.Attr) (context.Context, *Span) {
+	parent := SpanContextFrom(ctx)
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+		sc.TraceState = parent.TraceState
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = t.sampleRatio >= 1 || (t.sampleRatio > 0 && rand.Float64() < t.sampleRatio)
+	}
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:        name,
+			Kind:        kind,
+			SpanContext: sc,
+			Parent:      parent,
+			Start:       time.Now(),
+			Attrs:       slices.Clone(attrs),
+		},
+	}
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// Span is an operation of a trace
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext returns span context of the span
+func (s *Span) SpanContext() SpanContext {
+	return s.data.SpanContext
+}
+
+// IsRecording checks if span will be exported when it ends
+func (s *Span) IsRecording() bool {
+	return s.data.SpanContext.Sampled && s.tracer.exporter != nil
+}
+
+// SetName replaces name of the span (e.g. when route of the request becomes known)
+func (s *Span) SetName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Name = name
+}
+
+// SetAttrs adds attributes to the span
+func (s *Span) SetAttrs(attrs ...slog.Attr) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attrs = append(s.data.Attrs, attrs...)
+}
+
+// RecordError marks the span as failed with the error (nil errors are ignored)
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Err = err
+}
+
+// End finishes the span and exports it if it is sampled, subsequent calls are ignored
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+	if s.IsRecording() {
+		s.tracer.exporter.ExportSpan(data)
+	}
+}
+
+type spanContextKey struct{}
+type remoteSpanContextKey struct{}
+
+// SpanFrom returns current span of the context
+func SpanFrom(ctx context.Context) (*Span, bool) {
+	span, ok := ctx.Value(spanContextKey{}).(*Span)
+	return span, ok
+}
+
+// SpanContextFrom returns span context of the current span of the context, or remote span context
+// if there is no current span (invalid span context if there is neither)
+func SpanContextFrom(ctx context.Context) SpanContext {
+	if span, ok := SpanFrom(ctx); ok {
+		return span.SpanContext()
+	}
+	sc, _ := ctx.Value(remoteSpanContextKey{}).(SpanContext)
+	return sc
+}
+
+// ContextWithRemoteSpanContext returns a new context with span context received from another service,
+// spans started with the context continue its trace
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteSpanContextKey{}, sc)
+}
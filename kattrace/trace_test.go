@@ -0,0 +1,135 @@
+package kattrace_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/mobiletoly/gokatana/kattrace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := kattrace.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(t, sc.Sampled)
+	assert.True(t, sc.Remote)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.Traceparent())
+
+	sc, err = kattrace.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	require.NoError(t, err)
+	assert.False(t, sc.Sampled)
+
+	// future versions can have additional fields
+	_, err = kattrace.ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	assert.NoError(t, err)
+
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01",
+	} {
+		_, err := kattrace.ParseTraceparent(header)
+		assert.Error(t, err, header)
+	}
+}
+
+func TestStartSpans(t *testing.T) {
+	exporter := kattrace.NewInMemoryExporter()
+	tracer := kattrace.NewTracer(exporter, 1)
+
+	ctx, root := tracer.Start(context.Background(), "GET", kattrace.SpanKindServer, slog.String("url.path", "/users"))
+	childCtx, child := tracer.Start(ctx, "SELECT", kattrace.SpanKindClient)
+	assert.Equal(t, child.SpanContext(), kattrace.SpanContextFrom(childCtx))
+	child.RecordError(errors.New("connection refused"))
+	child.End()
+	root.SetName("GET /users")
+	root.SetAttrs(slog.Int("http.response.status_code", 200))
+	root.End()
+	root.End()
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "SELECT", spans[0].Name)
+	assert.Equal(t, kattrace.SpanKindClient, spans[0].Kind)
+	assert.EqualError(t, spans[0].Err, "connection refused")
+	assert.Equal(t, root.SpanContext().TraceID, spans[0].SpanContext.TraceID)
+	assert.Equal(t, root.SpanContext(), spans[0].Parent)
+
+	assert.Equal(t, "GET /users", spans[1].Name)
+	assert.False(t, spans[1].Parent.IsValid())
+	assert.NoError(t, spans[1].Err)
+	path, _ := spans[1].Attr("url.path")
+	assert.Equal(t, "/users", path.String())
+	status, _ := spans[1].Attr("http.response.status_code")
+	assert.EqualValues(t, 200, status.Int64())
+	assert.False(t, spans[1].End.Before(spans[1].Start))
+
+	exporter.Reset()
+	assert.Empty(t, exporter.Spans())
+}
+
+func TestRemoteSpanContext(t *testing.T) {
+	exporter := kattrace.NewInMemoryExporter()
+	tracer := kattrace.NewTracer(exporter, 0)
+
+	remote, err := kattrace.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	remote.TraceState = "vendor=value"
+	ctx := kattrace.ContextWithRemoteSpanContext(context.Background(), remote)
+	_, span := tracer.Start(ctx, "GET", kattrace.SpanKindServer)
+	span.End()
+
+	// traces sampled by caller are recorded regardless of sample ratio
+	spans := exporter.Spans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, remote.TraceID, spans[0].SpanContext.TraceID)
+	assert.NotEqual(t, remote.SpanID, spans[0].SpanContext.SpanID)
+	assert.Equal(t, "vendor=value", spans[0].SpanContext.TraceState)
+	assert.Equal(t, remote, spans[0].Parent)
+}
+
+func TestSampling(t *testing.T) {
+	exporter := kattrace.NewInMemoryExporter()
+	tracer := kattrace.NewTracer(exporter, 0)
+
+	ctx, root := tracer.Start(context.Background(), "job", kattrace.SpanKindInternal)
+	_, child := tracer.Start(ctx, "query", kattrace.SpanKindClient)
+	child.End()
+	root.End()
+
+	assert.True(t, root.SpanContext().IsValid())
+	assert.False(t, root.SpanContext().Sampled)
+	assert.False(t, root.IsRecording())
+	assert.Empty(t, exporter.Spans())
+	assert.Equal(t, root.SpanContext().TraceID, child.SpanContext().TraceID)
+}
+
+func TestDefaultTracer(t *testing.T) {
+	defaultTracer := kattrace.DefaultTracer()
+	t.Cleanup(func() {
+		kattrace.SetDefaultTracer(defaultTracer)
+	})
+
+	// spans of the default tracer are not exported, but trace context is still propagated
+	ctx, span := kattrace.Start(context.Background(), "GET", kattrace.SpanKindServer)
+	assert.False(t, span.IsRecording())
+	assert.True(t, kattrace.SpanContextFrom(ctx).IsValid())
+	span.End()
+
+	exporter := kattrace.NewInMemoryExporter()
+	kattrace.SetDefaultTracer(kattrace.NewTracer(exporter, 1))
+	_, span = kattrace.Start(context.Background(), "GET", kattrace.SpanKindServer)
+	span.End()
+	assert.Len(t, exporter.Spans(), 1)
+}